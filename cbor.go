@@ -0,0 +1,112 @@
+package hproblem
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// CBOREncoder encodes problems as CBOR maps (RFC 8949)
+// with the same members as JSONEncoder.
+// JSON numbers are encoded as integers if they fit in 64 bits
+// and as double-precision floats otherwise.
+var CBOREncoder Encoder = EncoderFunc(encodeCBOR)
+
+const (
+	cborUint   = 0 << 5
+	cborNegint = 1 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborSimple = 7 << 5
+
+	cborFalse   = cborSimple | 20
+	cborTrue    = cborSimple | 21
+	cborNull    = cborSimple | 22
+	cborFloat64 = cborSimple | 27
+)
+
+func encodeCBOR(w io.Writer, p *DetailsError) error {
+	members, err := problemMembers(p)
+	if err != nil {
+		return err
+	}
+
+	b := appendCBORHead(nil, cborMap, uint64(len(members)))
+	for _, m := range members {
+		b = appendCBORHead(b, cborText, uint64(len(m.name)))
+		b = append(b, m.name...)
+		b = appendCBORValue(b, m.value)
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(b, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return appendUint(append(b, major|26), n, 4)
+	default:
+		return appendUint(append(b, major|27), n, 8)
+	}
+}
+
+func appendUint(b []byte, n uint64, size int) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return append(b, buf[8-size:]...)
+}
+
+func appendCBORInt(b []byte, i int64) []byte {
+	if i < 0 {
+		return appendCBORHead(b, cborNegint, uint64(-1-i))
+	}
+	return appendCBORHead(b, cborUint, uint64(i))
+}
+
+func appendCBORValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, cborNull)
+	case bool:
+		if v {
+			return append(b, cborTrue)
+		}
+		return append(b, cborFalse)
+	case int:
+		return appendCBORInt(b, int64(v))
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return appendCBORInt(b, i)
+		}
+		f, _ := v.Float64()
+		return appendUint(append(b, cborFloat64), math.Float64bits(f), 8)
+	case string:
+		b = appendCBORHead(b, cborText, uint64(len(v)))
+		return append(b, v...)
+	case []interface{}:
+		b = appendCBORHead(b, cborArray, uint64(len(v)))
+		for _, item := range v {
+			b = appendCBORValue(b, item)
+		}
+		return b
+	case map[string]interface{}:
+		b = appendCBORHead(b, cborMap, uint64(len(v)))
+		for _, k := range sortedKeys(v) {
+			b = appendCBORHead(b, cborText, uint64(len(k)))
+			b = append(b, k...)
+			b = appendCBORValue(b, v[k])
+		}
+		return b
+	default:
+		return append(b, cborNull)
+	}
+}
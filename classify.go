@@ -0,0 +1,83 @@
+package hproblem
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// StatusClientClosedRequest is the non-standard status code
+// reported for context.Canceled.
+const StatusClientClosedRequest = 499
+
+// A Classifier reports the status code of err,
+// or false if it does not recognize err.
+// StatusCode calls the classifiers for every error in the chain of err
+// as it unwraps it, so a Classifier must not unwrap err itself.
+type Classifier func(err error) (statusCode int, ok bool)
+
+var classifiers = struct {
+	sync.RWMutex
+	list []Classifier
+}{
+	list: []Classifier{
+		ClassifyIs(os.ErrNotExist, http.StatusNotFound),
+		ClassifyIs(os.ErrPermission, http.StatusForbidden),
+		ClassifyIs(context.Canceled, StatusClientClosedRequest),
+		ClassifyIs(sql.ErrNoRows, http.StatusNotFound),
+		classifyStdlib,
+	},
+}
+
+// RegisterClassifier adds c in front of the registered classifiers,
+// so that it takes precedence over them.
+// The default classifiers report
+// 404 Not Found for os.ErrNotExist and sql.ErrNoRows,
+// 403 Forbidden for os.ErrPermission,
+// 499 Client Closed Request for context.Canceled,
+// 413 Request Entity Too Large for *http.MaxBytesError and
+// 400 Bad Request for *json.SyntaxError and *json.UnmarshalTypeError.
+func RegisterClassifier(c Classifier) {
+	classifiers.Lock()
+	defer classifiers.Unlock()
+	classifiers.list = append([]Classifier{c}, classifiers.list...)
+}
+
+// ClassifyIs returns a Classifier that reports statusCode for target
+// and for errors whose Is(error) bool method reports true for target.
+func ClassifyIs(target error, statusCode int) Classifier {
+	return func(err error) (int, bool) {
+		if err == target { //nolint
+			return statusCode, true
+		} else if x, ok := err.(interface{ Is(error) bool }); ok && x.Is(target) { //nolint
+			return statusCode, true
+		}
+		return 0, false
+	}
+}
+
+func classifyStdlib(err error) (int, bool) {
+	switch err.(type) { //nolint
+	case *http.MaxBytesError:
+		return http.StatusRequestEntityTooLarge, true
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		return http.StatusBadRequest, true
+	default:
+		return 0, false
+	}
+}
+
+// classify reports the status code of the first classifier that recognizes err.
+func classify(err error) (int, bool) {
+	classifiers.RLock()
+	defer classifiers.RUnlock()
+	for _, c := range classifiers.list {
+		if statusCode, ok := c(err); ok {
+			return statusCode, true
+		}
+	}
+	return 0, false
+}
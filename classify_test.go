@@ -0,0 +1,47 @@
+package hproblem
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+var errTestQuota = errors.New("quota exceeded")
+
+func init() {
+	RegisterClassifier(ClassifyIs(errTestQuota, http.StatusTooManyRequests))
+}
+
+func TestClassifier(t *testing.T) {
+	_, errNotExist := os.Open("/does/not/exist")
+	errSyntax := json.Unmarshal([]byte("{"), &struct{}{})
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader("0123456789"))
+	_, errMaxBytes := io.ReadAll(http.MaxBytesReader(nil, r.Body, 4))
+
+	for _, testCase := range []struct {
+		Err      error
+		Expected int
+	}{
+		{errNotExist, http.StatusNotFound},
+		{fmt.Errorf("open: %w", os.ErrPermission), http.StatusForbidden},
+		{context.Canceled, StatusClientClosedRequest},
+		{fmt.Errorf("query: %w", sql.ErrNoRows), http.StatusNotFound},
+		{errSyntax, http.StatusBadRequest},
+		{fmt.Errorf("read: %w", errMaxBytes), http.StatusRequestEntityTooLarge},
+		{errTestQuota, http.StatusTooManyRequests},
+		{fmt.Errorf("user: %w", Wrap(http.StatusBadRequest, errNotExist)), http.StatusBadRequest},
+	} {
+		if code := StatusCode(testCase.Err); code != testCase.Expected {
+			t.Error(testCase.Err, code, testCase.Expected)
+		}
+	}
+}
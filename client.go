@@ -0,0 +1,121 @@
+package hproblem
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxBodySize is the maximum number of bytes
+// of a response body that FromResponse reads.
+const DefaultMaxBodySize = 1 << 20
+
+// FromResponse returns nil if resp has a 2xx status code
+// and an error otherwise.
+// Bodies of type application/problem+json and application/problem+xml
+// are decoded by DetailsError.Unmarshal, or into a new value
+// of the Go type registered for the problem type by RegisterType.
+// Otherwise, the error is synthesized from the status line
+// and, if the body is plain text, its contents.
+// At most DefaultMaxBodySize bytes of the body are read.
+// The caller remains responsible for closing the body.
+func FromResponse(resp *http.Response) error {
+	return fromResponse(resp, DefaultMaxBodySize)
+}
+
+func fromResponse(resp *http.Response, maxBodySize int64) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var body []byte
+	var err error
+	if resp.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	if err == nil {
+		switch mediaType {
+		case "application/problem+json", "application/problem+xml":
+			if problem, p, err := decodeProblem(body); err == nil {
+				if p.Status == 0 {
+					p.Status = resp.StatusCode
+				}
+				return problem
+			}
+		}
+	}
+
+	p := &DetailsError{
+		Status:       resp.StatusCode,
+		Title:        statusLineText(resp),
+		wrappedError: err,
+	}
+
+	if err == nil && mediaType == "text/plain" {
+		p.Detail = strings.TrimSpace(string(body))
+	}
+	if p.Detail == "" {
+		p.Detail = p.Title
+	}
+
+	return p
+}
+
+// statusLineText returns the reason phrase of the status line of resp.
+func statusLineText(resp *http.Response) string {
+	text := strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode))
+	if text = strings.TrimSpace(text); text == "" {
+		text = StatusText(resp.StatusCode)
+	}
+	return text
+}
+
+// Transport is an http.RoundTripper that converts
+// responses with 4xx and 5xx status codes to errors using FromResponse.
+// Other responses are returned unchanged, so that http.Client
+// follows redirects and conditional requests get 304 Not Modified.
+// Unlike other RoundTrippers, it returns a nil response
+// and closes the body when it returns such an error.
+// The http.Client wraps it in a *url.Error, so use errors.As
+// or StatusCode to inspect it.
+type Transport struct {
+	// Base is the RoundTripper that makes the requests.
+	// http.DefaultTransport is used if it is nil.
+	Base http.RoundTripper
+
+	// MaxBodySize is the maximum number of bytes read from the body
+	// of an error response. DefaultMaxBodySize is used if it is zero.
+	MaxBodySize int64
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	maxBodySize := t.MaxBodySize
+	if maxBodySize == 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+
+	if resp.StatusCode < 400 {
+		return resp, nil
+	} else if err := fromResponse(resp, maxBodySize); err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+
+	return resp, nil
+}
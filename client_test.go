@@ -0,0 +1,134 @@
+package hproblem
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFromResponse(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusNoContent}
+		if err := FromResponse(resp); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		resp := &http.Response{
+			Status:     "403 Forbidden",
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{"Content-Type": {"application/problem+json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"title":"You do not have enough credit.","balance":30}`)),
+		}
+		var p *DetailsError
+		if err := FromResponse(resp); !errors.As(err, &p) {
+			t.Fatal(err)
+		} else if StatusCode(err) != http.StatusForbidden || p.Title != "You do not have enough credit." || p.Extensions["balance"] == nil {
+			t.Fatal(p)
+		}
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		resp := &http.Response{
+			Status:     "418 I'm a little teapot",
+			StatusCode: http.StatusTeapot,
+			Header:     http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+			Body:       io.NopCloser(strings.NewReader("short and stout\n")),
+		}
+		var p *DetailsError
+		if err := FromResponse(resp); !errors.As(err, &p) {
+			t.Fatal(err)
+		} else if p.Status != http.StatusTeapot || p.Title != "I'm a little teapot" || p.Detail != "short and stout" {
+			t.Fatal(p)
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		resp := &http.Response{
+			Status:     "400 Bad Request",
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{"Content-Type": {"application/problem+json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"detail":"` + strings.Repeat("x", DefaultMaxBodySize) + `"}`)),
+		}
+		var p *DetailsError
+		if err := FromResponse(resp); !errors.As(err, &p) {
+			t.Fatal(err)
+		} else if p.Status != http.StatusBadRequest || p.Detail != "Bad Request" {
+			t.Fatal(p)
+		}
+	})
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			return
+		case "/old":
+			ServeError(w, r, Redirect("/ok", http.StatusFound))
+			return
+		case "/cached":
+			ServeError(w, r, StatusNotModified)
+			return
+		}
+		ServeError(w, r, &DetailsError{
+			Instance: r.URL.Path,
+			Status:   http.StatusNotFound,
+		})
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+
+	t.Run("OK", func(t *testing.T) {
+		resp, err := client.Get(srv.URL + "/ok")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		resp, err := client.Get(srv.URL + "/old")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Request.URL.Path != "/ok" {
+			t.Fatal(resp.StatusCode, resp.Request.URL)
+		}
+
+		resp, err = client.Get(srv.URL + "/cached")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotModified {
+			t.Fatal(resp.StatusCode)
+		}
+	})
+
+	for _, accept := range []string{"application/json", "application/xml", "text/plain"} {
+		t.Run(accept, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", srv.URL+"/jedi", nil)
+			req.Header.Set("Accept", accept)
+			resp, err := client.Do(req)
+			if resp != nil {
+				t.Fatal(resp)
+			}
+
+			var p *DetailsError
+			if !errors.As(err, &p) {
+				t.Fatal(err)
+			} else if StatusCode(err) != http.StatusNotFound || p.Title != "Not Found" {
+				t.Fatal(p)
+			} else if accept != "text/plain" && p.Instance != "/jedi" {
+				t.Fatal(p)
+			}
+		})
+	}
+}
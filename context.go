@@ -0,0 +1,52 @@
+package hproblem
+
+import "context"
+
+type contextExtensionsKey struct{}
+
+// WithContextExtension returns a copy of ctx that carries an extension member.
+// ServeError adds the extension members carried by the request context
+// to the problem document if the Renderer allows them by name
+// in its ContextExtensions field. The members of the problem itself
+// take precedence.
+//
+//	ctx := hproblem.WithContextExtension(r.Context(), "tenant", tenant)
+//	next.ServeHTTP(w, r.WithContext(ctx))
+func WithContextExtension(ctx context.Context, name string, value interface{}) context.Context {
+	parent := contextExtensions(ctx)
+	members := make(map[string]interface{}, len(parent)+1)
+	for k, v := range parent {
+		members[k] = v
+	}
+	members[name] = value
+	return context.WithValue(ctx, contextExtensionsKey{}, members)
+}
+
+func contextExtensions(ctx context.Context) map[string]interface{} {
+	members, _ := ctx.Value(contextExtensionsKey{}).(map[string]interface{})
+	return members
+}
+
+// contextMembers returns the extension members carried by ctx
+// that are allowed by rr, or nil if there are none.
+func (rr *Renderer) contextMembers(ctx context.Context) map[string]interface{} {
+	if len(rr.ContextExtensions) == 0 {
+		return nil
+	}
+
+	all := contextExtensions(ctx)
+	if len(all) == 0 {
+		return nil
+	}
+
+	var members map[string]interface{}
+	for _, name := range rr.ContextExtensions {
+		if v, ok := all[name]; ok {
+			if members == nil {
+				members = make(map[string]interface{})
+			}
+			members[name] = v
+		}
+	}
+	return members
+}
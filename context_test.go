@@ -0,0 +1,41 @@
+package hproblem
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithContextExtension(t *testing.T) {
+	rr := NewRenderer()
+	rr.ContextExtensions = []string{"tenant", "request_id"}
+
+	handler := rr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return New(http.StatusNotFound, WithExtension("tenant", "jedi-order"))
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/json")
+	ctx := WithContextExtension(r.Context(), "request_id", "42")
+	ctx = WithContextExtension(ctx, "tenant", "empire")
+	ctx = WithContextExtension(ctx, "password", "hunter2")
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if b := w.Body.String(); b != `{"detail":"Not Found","status":404,"title":"Not Found","request_id":"42","tenant":"jedi-order"}`+"\n" {
+		t.Fatal(b)
+	}
+
+	w = httptest.NewRecorder()
+	rr.ServeError(w, r, StatusGone)
+	if b := w.Body.String(); b != `{"detail":"Gone","status":410,"title":"Gone","request_id":"42","tenant":"empire"}`+"\n" {
+		t.Fatal(b)
+	}
+
+	w = httptest.NewRecorder()
+	ServeError(w, r, StatusGone)
+	if b := w.Body.String(); b != `{"detail":"Gone","status":410,"title":"Gone"}`+"\n" {
+		t.Fatal(b)
+	}
+}
@@ -0,0 +1,179 @@
+package hproblem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DecodeOptions configures DecodeJSON.
+type DecodeOptions struct {
+	// MaxBytes is the maximum size of the request body in bytes.
+	// DefaultMaxBodySize is used if it is zero.
+	MaxBytes int64
+
+	// DisallowUnknownFields rejects objects with members
+	// that do not match an exported field of the destination.
+	DisallowUnknownFields bool
+}
+
+// DecodeJSON decodes the JSON request body into v.
+// The returned errors are ready to be served by ServeError:
+//
+//   - 415 Unsupported Media Type if the content type is not
+//     application/json or has a +json suffix.
+//   - 413 Request Entity Too Large if the body exceeds the maximum size.
+//   - A *ValidationError with status 400 Bad Request if the body is empty,
+//     is not valid JSON, contains more than one JSON value,
+//     has a member of the wrong type or, if disallowed, an unknown member.
+//     The invalid parameter is located by the JSON Pointer of the member
+//     and the byte offset in the body where the error was found.
+//
+// Errors that are not caused by the request, such as a v that is not
+// a non-nil pointer, are returned as is and served as 500 Internal Server Error.
+// A nil opts uses the defaults.
+func DecodeJSON(r *http.Request, v interface{}, opts *DecodeOptions) error {
+	if opts == nil {
+		opts = &DecodeOptions{}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" && !(strings.HasPrefix(mediaType, "application/") && suffix(mediaType) == "json") {
+		return New(http.StatusUnsupportedMediaType,
+			WithDetail("The request body must be of type application/json."),
+		)
+	}
+
+	maxBytes := opts.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxBodySize
+	}
+
+	body := r.Body
+	if body == nil {
+		body = http.NoBody
+	}
+
+	cr := &countingReader{r: http.MaxBytesReader(nil, body, maxBytes)}
+	dec := json.NewDecoder(cr)
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		offset := dec.InputOffset()
+		if err == io.ErrUnexpectedEOF {
+			// The decoder does not advance past an incomplete value,
+			// which ends where the body does.
+			offset = cr.n
+		}
+		return decodeError(err, offset)
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return decodeError(err, dec.InputOffset())
+		}
+		err = errors.New("json: invalid data after top-level value")
+		return invalidBody(err, "", "syntax", "must contain a single JSON value", dec.InputOffset())
+	}
+
+	return nil
+}
+
+// decodeError converts an error returned by json.Decoder.Decode to a problem.
+// The offset is that of the decoder after the error.
+func decodeError(err error, offset int64) error {
+	var tooLarge *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var invalidErr *json.InvalidUnmarshalError
+
+	switch {
+	case errors.As(err, &invalidErr):
+		// A programming error rather than a bad request.
+		return err
+	case errors.As(err, &tooLarge):
+		return PublicDetail(err, fmt.Sprintf("The request body must not exceed %d bytes.", tooLarge.Limit))
+	case err == io.EOF:
+		return invalidBody(err, "", "required", "must not be empty", 0)
+	case err == io.ErrUnexpectedEOF:
+		return invalidBody(err, "", "syntax", "unexpected end of JSON input", offset)
+	case errors.As(err, &syntaxErr):
+		return invalidBody(err, "", "syntax", strings.TrimPrefix(syntaxErr.Error(), "json: "), syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		var tokens []string
+		if typeErr.Field != "" {
+			tokens = strings.Split(typeErr.Field, ".")
+		}
+		reason := fmt.Sprintf("must be %s, not %s", jsonTypeName(typeErr.Type), typeErr.Value)
+		return invalidBody(err, JSONPointer(tokens...), "type", reason, typeErr.Offset)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return invalidBody(err, JSONPointer(name), "unknown", "is not a known member", offset)
+	default:
+		return Wrap(http.StatusBadRequest, err)
+	}
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// invalidBody returns a ValidationError with status 400 Bad Request
+// that reports a single invalid parameter of the request body.
+func invalidBody(err error, name, code, reason string, offset int64) error {
+	return &ValidationError{
+		DetailsError: &DetailsError{
+			Status:       http.StatusBadRequest,
+			wrappedError: err,
+		},
+		InvalidParams: []InvalidParam{{
+			Name:   name,
+			Reason: reason,
+			Code:   code,
+			Offset: offset,
+		}},
+	}
+}
+
+// jsonTypeName returns the name of the JSON type that decodes into t.
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "a value"
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a value"
+	}
+}
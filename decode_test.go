@@ -0,0 +1,97 @@
+package hproblem
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type jedi struct {
+		Name  string `json:"name"`
+		Skill struct {
+			Level int `json:"level"`
+		} `json:"skill"`
+	}
+
+	newRequest := func(contentType, body string) *http.Request {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		return r
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var v jedi
+		r := newRequest("application/json; charset=utf-8", `{"name":"obi-wan","skill":{"level":9}} `)
+		if err := DecodeJSON(r, &v, nil); err != nil {
+			t.Fatal(err)
+		} else if v.Name != "obi-wan" || v.Skill.Level != 9 {
+			t.Fatal(v)
+		}
+
+		r = newRequest("application/merge-patch+json", `{"name":"ben"}`)
+		if err := DecodeJSON(r, &v, nil); err != nil || v.Name != "ben" {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		var v jedi
+		err := DecodeJSON(newRequest("text/plain", `{}`), &v, nil)
+		if StatusCode(err) != http.StatusUnsupportedMediaType {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		var v jedi
+		err := DecodeJSON(newRequest("application/json", `{"name":"obi-wan"}`), &v, &DecodeOptions{MaxBytes: 8})
+		if StatusCode(err) != http.StatusRequestEntityTooLarge {
+			t.Fatal(err)
+		} else if d := NewDetailsError(err); d.Detail != "The request body must not exceed 8 bytes." {
+			t.Fatal(d.Detail)
+		}
+	})
+
+	t.Run("non-pointer", func(t *testing.T) {
+		var v jedi
+		err := DecodeJSON(newRequest("application/json", `{}`), v, nil)
+		if StatusCode(err) != http.StatusInternalServerError {
+			t.Fatal(err)
+		} else if d := NewDetailsError(err); d.Detail != "Internal Server Error" {
+			t.Fatal(d.Detail)
+		}
+	})
+
+	for _, test := range []struct {
+		Name  string
+		Body  string
+		Opts  *DecodeOptions
+		Param InvalidParam
+	}{
+		{"empty", ``, nil, InvalidParam{Name: "", Code: "required", Reason: "must not be empty"}},
+		{"syntax", `{"name":}`, nil, InvalidParam{Code: "syntax", Reason: "invalid character '}' looking for beginning of value", Offset: 9}},
+		{"truncated", `{"name":`, nil, InvalidParam{Code: "syntax", Reason: "unexpected end of JSON input", Offset: 8}},
+		{"trailing", `{}{}`, nil, InvalidParam{Code: "syntax", Reason: "must contain a single JSON value", Offset: 3}},
+		{"type", `{"skill":{"level":"high"}}`, nil, InvalidParam{Name: "/skill/level", Code: "type", Reason: "must be a number, not string", Offset: 24}},
+		{"unknown", `{"side":"light"}`, &DecodeOptions{DisallowUnknownFields: true}, InvalidParam{Name: "/side", Code: "unknown", Reason: "is not a known member", Offset: 16}},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			var v jedi
+			err := DecodeJSON(newRequest("application/json", test.Body), &v, test.Opts)
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatal(err)
+			} else if StatusCode(err) != http.StatusBadRequest {
+				t.Fatal(StatusCode(err))
+			} else if len(verr.InvalidParams) != 1 || verr.InvalidParams[0] != test.Param {
+				t.Fatal(verr.InvalidParams)
+			} else if errors.Unwrap(err) == nil {
+				t.Fatal()
+			}
+		})
+	}
+}
@@ -12,7 +12,8 @@ import (
 // DetailsError implements the RFC 7807 model.
 // See: https://datatracker.ietf.org/doc/html/rfc7807
 //
-// Additional fields can be added by embedding it inside another struct.
+// Extension members can be added to the Extensions map,
+// or by embedding it inside another struct.
 //
 //	type TraceDetailsError struct {
 //	    *hproblem.DetailsError
@@ -46,6 +47,18 @@ type DetailsError struct {
 	// "about:blank".
 	Type string `json:"type,omitempty" xml:"type,omitempty"`
 
+	// Extensions holds the extension members of the problem.
+	// ServeError and the Encoders of this package render them as additional
+	// members of the JSON object and as child elements in XML,
+	// following RFC 7807, Appendix A.
+	// Members named after one of the fields above are ignored.
+	// Unmarshal collects the unknown members of a problem in this map.
+	//
+	// The map is not encoded by encoding/json and encoding/xml,
+	// so that types embedding DetailsError keep their own encoding.
+	// Use JSONEncoder or XMLEncoder to encode a DetailsError with its extensions.
+	Extensions map[string]interface{} `json:"-" xml:"-"`
+
 	// XMLName is needed to marshal to XML.
 	XMLName xml.Name `json:"-" xml:"urn:ietf:rfc:7807 problem"`
 
@@ -61,27 +74,140 @@ func (details *DetailsError) StatusCode() int { return details.Status }
 // Unwrap implements the interface used by errors.Unwrap() and returns the wrapped error.
 func (details *DetailsError) Unwrap() error { return details.wrappedError }
 
+// Is reports whether target is the Status* error of the Status field
+// or the Kind of the Type field.
+func (details *DetailsError) Is(target error) bool {
+	return details != nil && isProblem(target, details.Status, details.Type)
+}
+
+// As sets a *DetailsError target to details,
+// so that errors.As finds the DetailsError embedded inside another type.
+func (details *DetailsError) As(target interface{}) bool {
+	p, ok := target.(**DetailsError)
+	if !ok || details == nil {
+		return false
+	}
+	*p = details
+	return true
+}
+
+// publicDetail makes the Detail field of a problem document public,
+// so that wrapping it does not change or redact its detail.
+func (details *DetailsError) publicDetail() (string, bool) {
+	if details == nil {
+		return "", true
+	}
+	return details.Detail, true
+}
+
+func (details *DetailsError) extensionMembers() map[string]interface{} {
+	if details == nil {
+		return nil
+	}
+	return details.Extensions
+}
+
 // NewDetailsError returns a new DetailsError with the
-// Detail, Status and Title fields set according to err.
+// Detail, Status and Title fields set according to err,
+// and the members set by New and Decorate in the chain of err.
+// Status codes out of the range 100-599 become 500 Internal Server Error.
+// If err joins multiple errors, as returned by errors.Join,
+// the "errors" extension member holds a []*DetailsError
+// converted from each of them.
+//
+// Use AsDetails to also merge the members of the problem documents
+// in the chain of err.
+//
+// The message of err is only used as the detail if it is public.
+// By default, the messages of client errors are public
+// and those of server errors are private.
+// The detail of a DetailsError in the chain of err is always public.
+// Use Public, Private and PublicDetail to decide otherwise.
+// A private message is replaced by the status text,
+// and the Instance field is set to a unique identifier
+// that is reported to OnRedact together with err.
+// The detail of a multi-error is made of the details of the errors it joins,
+// and is redacted if any of them is. The problems of the joined errors
+// share the identifier, which is reported once.
 func NewDetailsError(err error) *DetailsError {
-	var detail string
-	if err != nil {
-		detail = err.Error()
-	}
+	return newDetailsError(err, nil)
+}
 
+func newDetailsError(err error, occ *occurrence) *DetailsError {
 	statusCode := StatusCode(err)
 
-	return &DetailsError{
-		Detail:       detail,
+	details := &DetailsError{
 		Status:       statusCode,
-		Title:        http.StatusText(statusCode),
 		wrappedError: err,
 	}
+
+	decorate(details, err)
+	completeDetails(details, err, occ, newDetailsError)
+	return details
+}
+
+// completeDetails defaults the Title field, sets the Detail field
+// to the public detail of err and adds the "errors" extension member
+// converted by convert from the errors joined by err.
+//
+// The problems converted from the same error share the occurrence occ,
+// which is nil or not yet started for the outermost one.
+// It reports the occurrence once if any of them is redacted.
+func completeDetails(details *DetailsError, err error, occ *occurrence, convert func(error, *occurrence) *DetailsError) {
+	if occ == nil {
+		occ = &occurrence{}
+	}
+	outermost := !occ.started
+	occ.started = true
+
+	if !validStatus(details.Status) {
+		details.Status = http.StatusInternalServerError
+	}
+	if details.Title == "" {
+		details.Title = StatusText(details.Status)
+	}
+
+	if err != nil {
+		var redacted bool
+		if details.Detail, redacted = redact(err, details.Status); redacted {
+			details.Instance = occ.identify(details.Instance)
+		}
+	}
+
+	if errs := joinedErrors(err); len(errs) > 0 {
+		problems := make([]*DetailsError, len(errs))
+		for i, err := range errs {
+			problems[i] = convert(err, occ)
+		}
+		if details.Extensions == nil {
+			details.Extensions = make(map[string]interface{})
+		}
+		if _, ok := details.Extensions["errors"]; !ok {
+			details.Extensions["errors"] = problems
+		}
+	}
+
+	if outermost && occ.id != "" {
+		occ.reportRedacted(err)
+	}
+}
+
+// joinedErrors returns the errors joined by the first multi-error in the chain of err.
+func joinedErrors(err error) []error {
+	for ; err != nil; err = unwrap(err) {
+		if u, ok := err.(interface{ Unwrap() []error }); ok {
+			return u.Unwrap()
+		}
+	}
+	return nil
 }
 
 var ErrInvalidEncoding = errors.New("hproblem: invalid details error encoding")
 
 // Unmarshal parses a JSON or XML encoded details error.
+// Unknown members are added to Extensions.
+// JSON numbers are decoded as json.Number
+// and XML elements without children as strings.
 // Returns ErrInvalidEncoding if the encoding is invalid.
 func (details *DetailsError) Unmarshal(data []byte) error {
 	data = bytes.TrimLeftFunc(data, unicode.IsSpace)
@@ -89,12 +215,28 @@ func (details *DetailsError) Unmarshal(data []byte) error {
 		return ErrInvalidEncoding
 	}
 
+	var members map[string]interface{}
 	switch data[0] {
 	case '{':
-		return json.Unmarshal(data, details)
+		if err := json.Unmarshal(data, details); err != nil {
+			return err
+		}
+		members, _ = decodeJSONMembers(data)
 	case '<':
-		return xml.Unmarshal(data, details)
+		if err := xml.Unmarshal(data, details); err != nil {
+			return err
+		}
+		members, _ = decodeXMLMembers(data)
 	default:
 		return ErrInvalidEncoding
 	}
+
+	for name, value := range members {
+		if details.Extensions == nil {
+			details.Extensions = make(map[string]interface{}, len(members))
+		}
+		details.Extensions[name] = value
+	}
+
+	return nil
 }
@@ -1,8 +1,10 @@
 package hproblem
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -56,6 +58,32 @@ func TestProblemDetailsError(t *testing.T) {
 	})
 }
 
+type testTraceDetails struct {
+	*DetailsError
+	TraceID string `json:"traceId" xml:"trace-id"`
+	Attr    string `json:"attr" xml:"attr,attr"`
+}
+
+func TestEmbeddedXML(t *testing.T) {
+	err := fmt.Errorf("trace: %w", &testTraceDetails{
+		DetailsError: &DetailsError{
+			Status:     http.StatusBadRequest,
+			Extensions: map[string]interface{}{"span": "z"},
+		},
+		TraceID: "x",
+		Attr:    "y",
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/xml")
+	ServeError(w, r, err)
+	b := w.Body.String()
+	if b != xml.Header+`<problem xmlns="urn:ietf:rfc:7807" attr="y"><status>400</status><title>Bad Request</title><trace-id>x</trace-id><span>z</span></problem>` {
+		t.Fatal(b)
+	}
+}
+
 func TestUnmarshal(t *testing.T) {
 	t.Run("json", func(t *testing.T) {
 		x := []byte(`{"detail":"error","status":400,"title":"Bad Request"}`)
@@ -107,3 +135,92 @@ func TestDetailsUnwrap(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestExtensions(t *testing.T) {
+	detail := &DetailsError{
+		Detail: "Your current balance is 30, but that costs 50.",
+		Status: http.StatusForbidden,
+		Title:  "You do not have enough credit.",
+		Type:   "https://example.com/probs/out-of-credit",
+		Extensions: map[string]interface{}{
+			"balance":  30,
+			"accounts": []string{"/account/12345", "/account/67890"},
+			"limits":   map[string]int{"daily": 100},
+			"title":    "ignored",
+		},
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		ServeError(w, r, detail)
+		b := w.Body.String()
+		if b != `{"detail":"Your current balance is 30, but that costs 50.","status":403,"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit","accounts":["/account/12345","/account/67890"],"balance":30,"limits":{"daily":100}}`+"\n" {
+			t.Fatal(b)
+		}
+
+		var dt DetailsError
+		if err := dt.Unmarshal([]byte(b)); err != nil {
+			t.Fatal(err)
+		}
+		if len(dt.Extensions) != 3 || dt.Extensions["balance"] != json.Number("30") {
+			t.Fatal(dt.Extensions)
+		}
+		if accounts, _ := dt.Extensions["accounts"].([]interface{}); len(accounts) != 2 || accounts[1] != "/account/67890" {
+			t.Fatal(dt.Extensions)
+		}
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/xml")
+		ServeError(w, r, detail)
+		b := w.Body.String()
+		if b != xml.Header+`<problem xmlns="urn:ietf:rfc:7807"><detail>Your current balance is 30, but that costs 50.</detail><status>403</status><title>You do not have enough credit.</title><type>https://example.com/probs/out-of-credit</type><accounts><i>/account/12345</i><i>/account/67890</i></accounts><balance>30</balance><limits><daily>100</daily></limits></problem>` {
+			t.Fatal(b)
+		}
+
+		var dt DetailsError
+		if err := dt.Unmarshal([]byte(b)); err != nil {
+			t.Fatal(err)
+		}
+		if len(dt.Extensions) != 3 || dt.Extensions["balance"] != "30" {
+			t.Fatal(dt.Extensions)
+		}
+		if accounts, _ := dt.Extensions["accounts"].([]interface{}); len(accounts) != 2 || accounts[1] != "/account/67890" {
+			t.Fatal(dt.Extensions)
+		}
+		if limits, _ := dt.Extensions["limits"].(map[string]interface{}); limits["daily"] != "100" {
+			t.Fatal(dt.Extensions)
+		}
+	})
+}
+
+func TestDetailsJoined(t *testing.T) {
+	err := errors.Join(
+		Wrap(http.StatusBadRequest, errors.New("name is required")),
+		Wrap(http.StatusUnprocessableEntity, errors.Join(
+			Wrap(http.StatusUnprocessableEntity, errors.New("age must be positive")),
+		)),
+	)
+
+	details := NewDetailsError(err)
+	if details.Status != http.StatusUnprocessableEntity {
+		t.Fatal(details.Status)
+	}
+
+	problems, _ := details.Extensions["errors"].([]*DetailsError)
+	if len(problems) != 2 || problems[0].Status != http.StatusBadRequest || problems[0].Detail != "name is required" {
+		t.Fatal(problems)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/json")
+	ServeError(w, r, details)
+	if b := w.Body.String(); b != `{"detail":"name is required\nage must be positive","status":422,"title":"Unprocessable Entity","errors":[{"detail":"name is required","status":400,"title":"Bad Request"},{"detail":"age must be positive","errors":[{"detail":"age must be positive","status":422,"title":"Unprocessable Entity"}],"status":422,"title":"Unprocessable Entity"}]}`+"\n" {
+		t.Fatal(b)
+	}
+}
@@ -0,0 +1,210 @@
+package hproblem
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"reflect"
+)
+
+// Encoder writes the representation of a problem to w.
+type Encoder interface {
+	Encode(w io.Writer, p *DetailsError) error
+}
+
+// EncoderFunc adapts a function to the Encoder interface.
+type EncoderFunc func(w io.Writer, p *DetailsError) error
+
+// Encode implements the Encoder interface by calling f(w, p).
+func (f EncoderFunc) Encode(w io.Writer, p *DetailsError) error {
+	return f(w, p)
+}
+
+var (
+	// JSONEncoder encodes problems as JSON objects.
+	// Extension members follow the standard members in lexicographic order.
+	JSONEncoder Encoder = &builtinEncoder{encodeJSON, formatJSON}
+
+	// XMLEncoder encodes problems as XML documents
+	// as specified by RFC 7807, Appendix A.
+	// A problem converted from an error that embeds DetailsError
+	// is encoded from that error, so that its xml struct tags apply.
+	XMLEncoder Encoder = &builtinEncoder{encodeXML, formatXML}
+
+	// TextEncoder encodes problems as a single line of plain text
+	// holding the Detail field, or the Title field if Detail is empty.
+	TextEncoder Encoder = &builtinEncoder{encodeText, formatText}
+)
+
+// The formats of the builtin encoders.
+const (
+	formatJSON = iota
+	formatXML
+	formatText
+	numFormats
+)
+
+// builtinEncoder is an encoder whose encodings of the Status* errors are prebuilt.
+type builtinEncoder struct {
+	encode func(w io.Writer, p *DetailsError) error
+	format int
+}
+
+func (enc *builtinEncoder) Encode(w io.Writer, p *DetailsError) error {
+	return enc.encode(w, p)
+}
+
+func encodeJSON(w io.Writer, p *DetailsError) error {
+	b, err := marshalJSON(p)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func marshalJSON(p *DetailsError) ([]byte, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return appendJSONMembers(b, p.Extensions)
+}
+
+func encodeXML(w io.Writer, p *DetailsError) error {
+	b, err := marshalXML(p)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func marshalXML(p *DetailsError) ([]byte, error) {
+	var v interface{} = p
+	members := p.Extensions
+	if e, fields := embeddingOf(p); e != nil {
+		v = e
+		members = make(map[string]interface{}, len(p.Extensions))
+		for name, value := range p.Extensions {
+			if _, ok := fields[name]; !ok {
+				members[name] = value
+			}
+		}
+	}
+
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendXMLMembers(b, members)
+}
+
+// embeddingOf returns a copy of the outermost error in the chain of p
+// that embeds DetailsError, with the standard members of p in place of
+// the embedded DetailsError, and the names of its JSON members.
+// It returns nil if there is no such error.
+func embeddingOf(p *DetailsError) (interface{}, map[string]json.RawMessage) {
+	for err := p.wrappedError; err != nil; err = unwrap(err) {
+		if _, ok := err.(*DetailsError); ok {
+			continue
+		} else if _, ok := err.(interface{ extensionMembers() map[string]interface{} }); !ok {
+			continue
+		}
+
+		v := reflect.ValueOf(err)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return nil, nil
+		}
+
+		field, ok := v.Type().FieldByName("DetailsError")
+		if !ok || len(field.Index) != 1 {
+			return nil, nil
+		}
+
+		var fields map[string]json.RawMessage
+		if b, err := json.Marshal(err); err != nil || json.Unmarshal(b, &fields) != nil {
+			return nil, nil
+		}
+
+		standard := *p
+		standard.Extensions = nil
+
+		cp := reflect.New(v.Type())
+		cp.Elem().Set(v)
+		switch f := cp.Elem().Field(field.Index[0]); f.Type() {
+		case reflect.TypeOf(p):
+			f.Set(reflect.ValueOf(&standard))
+		case reflect.TypeOf(standard):
+			f.Set(reflect.ValueOf(standard))
+		default:
+			return nil, nil
+		}
+
+		return cp.Interface(), fields
+	}
+	return nil, nil
+}
+
+func encodeText(w io.Writer, p *DetailsError) error {
+	text := p.Detail
+	if text == "" {
+		text = p.Title
+	}
+	_, err := io.WriteString(w, text+"\n")
+	return err
+}
+
+// member is a name/value pair of a problem document.
+type member struct {
+	name  string
+	value interface{}
+}
+
+// problemMembers returns the members of p in the order of JSONEncoder.
+// Extension values are normalized by normalizeMember.
+func problemMembers(p *DetailsError) ([]member, error) {
+	return problemMembersDepth(p, 0)
+}
+
+func problemMembersDepth(p *DetailsError, depth int) ([]member, error) {
+	members := make([]member, 0, 5+len(p.Extensions))
+	for _, m := range []member{
+		{"detail", p.Detail},
+		{"instance", p.Instance},
+		{"status", p.Status},
+		{"title", p.Title},
+		{"type", p.Type},
+	} {
+		if m.value != "" && m.value != 0 {
+			members = append(members, m)
+		}
+	}
+
+	for _, name := range sortedKeys(p.Extensions) {
+		if isStandardMember(name) {
+			continue
+		}
+
+		value, err := normalizeMemberDepth(p.Extensions[name], depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, member{name, value})
+	}
+
+	return members, nil
+}
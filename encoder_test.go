@@ -0,0 +1,74 @@
+package hproblem
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEncoders(t *testing.T) {
+	p := &DetailsError{
+		Status: http.StatusNotFound,
+		Title:  "Not Found",
+		Extensions: map[string]interface{}{
+			"f":     1.5,
+			"l":     []interface{}{nil},
+			"n":     -2,
+			"ok":    true,
+			"on":    map[string]string{"a b": "\"x\""},
+			"empty": []int{},
+		},
+	}
+
+	t.Run("CBOR", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := CBOREncoder.Encode(&buf, p); err != nil {
+			t.Fatal(err)
+		}
+		expected := "a8" +
+			"66737461747573" + "190194" +
+			"657469746c65" + "694e6f7420466f756e64" +
+			"65656d707479" + "80" +
+			"6166" + "fb3ff8000000000000" +
+			"616c" + "81f6" +
+			"616e" + "21" +
+			"626f6b" + "f5" +
+			"626f6e" + "a1" + "63612062" + "63227822"
+		if x := hex.EncodeToString(buf.Bytes()); x != expected {
+			t.Fatal(x)
+		}
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := YAMLEncoder.Encode(&buf, p); err != nil {
+			t.Fatal(err)
+		}
+		expected := `status: 404
+title: "Not Found"
+empty: []
+f: 1.5
+l:
+  - null
+"n": -2
+ok: true
+"on":
+  "a b": "\"x\""
+`
+		if buf.String() != expected {
+			t.Fatal(buf.String())
+		}
+	})
+
+	t.Run("Negotiate", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/cbor")
+		ServeError(w, r, StatusNotFound)
+		if w.Header().Get("Content-Type") != "application/problem+cbor" {
+			t.Fatal(w.Header())
+		}
+	})
+}
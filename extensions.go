@@ -0,0 +1,382 @@
+package hproblem
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// isStandardMember reports whether name is one of the members defined by RFC 7807.
+// Extension members by these names are never encoded.
+func isStandardMember(name string) bool {
+	switch strings.ToLower(name) {
+	case "detail", "instance", "status", "title", "type":
+		return true
+	default:
+		return false
+	}
+}
+
+// extensionsOf returns the extension members of v
+// if v is or embeds a DetailsError.
+func extensionsOf(v interface{}) map[string]interface{} {
+	if x, ok := v.(interface {
+		extensionMembers() map[string]interface{}
+	}); ok {
+		return x.extensionMembers()
+	}
+	return nil
+}
+
+func sortedKeys(members map[string]interface{}) []string {
+	keys := make([]string, 0, len(members))
+	for k := range members {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendJSONMembers adds the members to the JSON object obj
+// in lexicographic order, skipping those that obj already has.
+func appendJSONMembers(obj []byte, members map[string]interface{}) ([]byte, error) {
+	if len(members) == 0 {
+		return obj, nil
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(obj, &present); err != nil {
+		return nil, err
+	}
+
+	obj = bytes.TrimSpace(obj)
+	buf := bytes.NewBuffer(make([]byte, 0, 2*len(obj)))
+	buf.Write(obj[:len(obj)-1])
+
+	n := len(present)
+	for _, name := range sortedKeys(members) {
+		if _, ok := present[name]; ok || isStandardMember(name) {
+			continue
+		}
+
+		normalized, err := normalizeMember(members[name])
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(normalized)
+		if err != nil {
+			return nil, err
+		}
+
+		key, _ := json.Marshal(name)
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		n++
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// appendXMLMembers adds the members as child elements of the root element of doc
+// in lexicographic order, following RFC 7807, Appendix A.
+// Members whose names are not valid XML names are skipped.
+func appendXMLMembers(doc []byte, members map[string]interface{}) ([]byte, error) {
+	if len(members) == 0 {
+		return doc, nil
+	}
+
+	end := bytes.LastIndex(doc, []byte("</"))
+	if end < 0 {
+		return nil, ErrInvalidEncoding
+	}
+
+	var buf bytes.Buffer
+	buf.Write(doc[:end])
+
+	e := xml.NewEncoder(&buf)
+	if err := encodeXMLMembers(e, members); err != nil {
+		return nil, err
+	}
+
+	if err := e.Flush(); err != nil {
+		return nil, err
+	}
+
+	buf.Write(doc[end:])
+	return buf.Bytes(), nil
+}
+
+// encodeXMLMembers encodes the members as elements in lexicographic order.
+// Members whose names are not valid XML names are skipped.
+func encodeXMLMembers(e *xml.Encoder, members map[string]interface{}) error {
+	for _, name := range sortedKeys(members) {
+		if isStandardMember(name) || !isXMLName(name) {
+			continue
+		}
+
+		value, err := normalizeMember(members[name])
+		if err != nil {
+			return err
+		}
+
+		if err := encodeXMLMember(e, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxMemberDepth limits the nesting of extension members,
+// which stops the normalization of cyclic values.
+const maxMemberDepth = 1000
+
+var errMemberDepth = errors.New("hproblem: extension member is cyclic or nested too deeply")
+
+// normalizeMember converts v to a tree of
+// map[string]interface{}, []interface{}, string, bool, json.Number and nil.
+// Problems nested in v are converted with their extension members.
+// It returns an error if v is cyclic.
+func normalizeMember(v interface{}) (interface{}, error) {
+	return normalizeMemberDepth(v, 0)
+}
+
+func normalizeMemberDepth(v interface{}, depth int) (interface{}, error) {
+	if depth > maxMemberDepth {
+		return nil, errMemberDepth
+	}
+
+	switch v := v.(type) {
+	case nil, string, bool, json.Number:
+		return v, nil
+	case *DetailsError:
+		members, err := problemMembersDepth(v, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]interface{}, len(members))
+		for _, member := range members {
+			m[member.name] = member.value
+		}
+		return normalizeMemberDepth(m, depth+1)
+	case []*DetailsError:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			x, err := normalizeMemberDepth(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = x
+		}
+		return items, nil
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			x, err := normalizeMemberDepth(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = x
+		}
+		return items, nil
+	case map[string]interface{}:
+		members := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			x, err := normalizeMemberDepth(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			members[k] = x
+		}
+		return members, nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return decodeJSONMember(b)
+	}
+}
+
+func decodeJSONMember(data []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func encodeXMLMember(e *xml.Encoder, name string, v interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+	case []interface{}:
+		for _, item := range v {
+			if err := encodeXMLMember(e, "i", item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			if !isXMLName(k) {
+				continue
+			}
+			if err := encodeXMLMember(e, k, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := e.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// decodeJSONMembers returns the members of the JSON object data
+// that are not defined by RFC 7807.
+func decodeJSONMembers(data []byte) (map[string]interface{}, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var members map[string]interface{}
+	for name, value := range raw {
+		if isStandardMember(name) {
+			continue
+		}
+
+		v, err := decodeJSONMember(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if members == nil {
+			members = make(map[string]interface{})
+		}
+		members[name] = v
+	}
+
+	return members, nil
+}
+
+// decodeXMLMembers returns the child elements of the root element of data
+// that are not defined by RFC 7807, following RFC 7807, Appendix A.
+// Elements that only contain <i> elements are decoded as arrays,
+// other elements with children as objects and the rest as strings.
+func decodeXMLMembers(data []byte) (map[string]interface{}, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		} else if _, ok := tok.(xml.StartElement); ok {
+			break
+		}
+	}
+
+	var members map[string]interface{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if isStandardMember(t.Name.Local) {
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			v, err := decodeXMLMember(d)
+			if err != nil {
+				return nil, err
+			}
+
+			if members == nil {
+				members = make(map[string]interface{})
+			}
+			members[t.Name.Local] = v
+		case xml.EndElement:
+			return members, nil
+		}
+	}
+}
+
+func decodeXMLMember(d *xml.Decoder) (interface{}, error) {
+	var text strings.Builder
+	var names []string
+	var values []interface{}
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			v, err := decodeXMLMember(d)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, t.Name.Local)
+			values = append(values, v)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(names) == 0 {
+				return text.String(), nil
+			}
+
+			for _, name := range names {
+				if name != "i" {
+					members := make(map[string]interface{}, len(names))
+					for i, name := range names {
+						members[name] = values[i]
+					}
+					return members, nil
+				}
+			}
+
+			return values, nil
+		}
+	}
+}
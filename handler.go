@@ -0,0 +1,227 @@
+package hproblem
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"net"
+	"net/http"
+)
+
+// HandlerFunc is an http.Handler that returns an error.
+// A non-nil error is rendered by ServeError,
+// unless the handler has already written the response headers,
+// in which case DefaultRenderer.OnCommitted is called instead.
+// The Cache-Control, Content-Encoding, ETag and Last-Modified header fields
+// set by the handler are deleted before rendering the error.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP implements the http.Handler interface.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	DefaultRenderer.serveHandler(w, r, f)
+}
+
+// Handler returns an http.Handler that calls f and renders
+// a non-nil error with rr.ServeError,
+// unless f has already written the response headers,
+// in which case rr.OnCommitted is called instead.
+func (rr *Renderer) Handler(f HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rr.serveHandler(w, r, f)
+	})
+}
+
+func (rr *Renderer) serveHandler(w http.ResponseWriter, r *http.Request, f HandlerFunc) {
+	rw := &responseWriter{ResponseWriter: w}
+	if err := f(rw.expose(), r); err != nil {
+		if rw.committed {
+			rr.committed(w, r, err)
+		} else {
+			clearContentHeader(w.Header())
+			rr.ServeError(w, r, err)
+		}
+	}
+}
+
+// clearContentHeader deletes the header fields that a handler may have set
+// to describe the content it was going to write, which would mislabel
+// the problem or make it cacheable, like http.ServeContent does on errors.
+func clearContentHeader(h http.Header) {
+	h.Del("Cache-Control")
+	h.Del("Content-Encoding")
+	h.Del("ETag")
+	h.Del("Last-Modified")
+}
+
+func (rr *Renderer) committed(w http.ResponseWriter, r *http.Request, err error) {
+	if rr.OnCommitted != nil {
+		rr.OnCommitted(w, r, err)
+	} else {
+		log.Printf("hproblem: %s %s: response already written: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// responseWriter records whether the response headers have been written.
+type responseWriter struct {
+	http.ResponseWriter
+	committed bool
+}
+
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	// Informational responses other than 101 are not final.
+	if statusCode >= 200 || statusCode == http.StatusSwitchingProtocols {
+		rw.committed = true
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	rw.committed = true
+	return rw.ResponseWriter.Write(p)
+}
+
+func (rw *responseWriter) Flush() {
+	rw.committed = true
+	rw.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rw.committed = true
+	return rw.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (rw *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	rw.committed = true
+	return rw.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
+
+func (rw *responseWriter) Push(target string, opts *http.PushOptions) error {
+	return rw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// unwrapper is the part of responseWriter that is always exposed.
+type unwrapper interface {
+	http.ResponseWriter
+	Unwrap() http.ResponseWriter
+}
+
+// expose returns rw as an http.ResponseWriter that implements
+// http.Flusher, http.Hijacker, io.ReaderFrom and http.Pusher
+// only if the underlying writer does.
+func (rw *responseWriter) expose() http.ResponseWriter {
+	var mask int
+	if _, ok := rw.ResponseWriter.(http.Flusher); ok {
+		mask |= 1
+	}
+	if _, ok := rw.ResponseWriter.(http.Hijacker); ok {
+		mask |= 2
+	}
+	if _, ok := rw.ResponseWriter.(io.ReaderFrom); ok {
+		mask |= 4
+	}
+	if _, ok := rw.ResponseWriter.(http.Pusher); ok {
+		mask |= 8
+	}
+
+	switch mask {
+	case 1:
+		return struct {
+			unwrapper
+			http.Flusher
+		}{rw, rw}
+	case 2:
+		return struct {
+			unwrapper
+			http.Hijacker
+		}{rw, rw}
+	case 3:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Hijacker
+		}{rw, rw, rw}
+	case 4:
+		return struct {
+			unwrapper
+			io.ReaderFrom
+		}{rw, rw}
+	case 5:
+		return struct {
+			unwrapper
+			http.Flusher
+			io.ReaderFrom
+		}{rw, rw, rw}
+	case 6:
+		return struct {
+			unwrapper
+			http.Hijacker
+			io.ReaderFrom
+		}{rw, rw, rw}
+	case 7:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{rw, rw, rw, rw}
+	case 8:
+		return struct {
+			unwrapper
+			http.Pusher
+		}{rw, rw}
+	case 9:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Pusher
+		}{rw, rw, rw}
+	case 10:
+		return struct {
+			unwrapper
+			http.Hijacker
+			http.Pusher
+		}{rw, rw, rw}
+	case 11:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{rw, rw, rw, rw}
+	case 12:
+		return struct {
+			unwrapper
+			io.ReaderFrom
+			http.Pusher
+		}{rw, rw, rw}
+	case 13:
+		return struct {
+			unwrapper
+			http.Flusher
+			io.ReaderFrom
+			http.Pusher
+		}{rw, rw, rw, rw}
+	case 14:
+		return struct {
+			unwrapper
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{rw, rw, rw, rw}
+	case 15:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{rw, rw, rw, rw, rw}
+	default:
+		return struct{ unwrapper }{rw}
+	}
+}
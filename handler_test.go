@@ -0,0 +1,144 @@
+package hproblem
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandlerFunc(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			w.Header().Set("Link", "</>")
+			return StatusTeapot
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusTeapot || w.Body.String() != "I'm a teapot\n" || w.Header().Get("Link") != "</>" {
+			t.Fatal(w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusCreated)
+			return nil
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusCreated || w.Body.Len() != 0 {
+			t.Fatal(w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("committed", func(t *testing.T) {
+		errPartial := errors.New("partial")
+
+		var committed error
+		rr := NewRenderer()
+		rr.OnCommitted = func(w http.ResponseWriter, r *http.Request, err error) {
+			committed = err
+		}
+
+		h := rr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusEarlyHints)
+			_, _ = io.WriteString(w, "partial")
+			return errPartial
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		h.ServeHTTP(w, r)
+		if committed != errPartial {
+			t.Fatal(committed)
+		} else if w.Body.String() != "partial" {
+			t.Fatal(w.Body.String())
+		}
+	})
+
+	t.Run("content header", func(t *testing.T) {
+		h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.Header().Set("Cache-Control", "max-age=3600")
+			w.Header().Set("Link", "</>")
+			return Errorf(http.StatusNotFound, "no such jedi")
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		h.ServeHTTP(w, r)
+		for _, name := range []string{"Content-Encoding", "ETag", "Last-Modified", "Cache-Control"} {
+			if v := w.Header().Get(name); v != "" {
+				t.Error(name, v)
+			}
+		}
+		if w.Code != http.StatusNotFound || w.Header().Get("Link") != "</>" {
+			t.Fatal(w.Code, w.Header())
+		}
+	})
+
+	t.Run("informational", func(t *testing.T) {
+		h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusEarlyHints)
+			return StatusNotFound
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		h.ServeHTTP(w, r)
+		if w.Body.String() != "Not Found\n" {
+			t.Fatal(w.Body.String())
+		}
+	})
+
+	t.Run("interfaces", func(t *testing.T) {
+		var committed error
+		rr := NewRenderer()
+		rr.OnCommitted = func(w http.ResponseWriter, r *http.Request, err error) {
+			committed = err
+		}
+
+		h := rr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			if _, ok := w.(http.Flusher); !ok {
+				t.Error("not a Flusher")
+			} else if _, ok := w.(io.ReaderFrom); ok {
+				t.Error("ReaderFrom")
+			} else if _, ok := w.(http.Pusher); ok {
+				t.Error("Pusher")
+			}
+			if _, _, err := w.(http.Hijacker).Hijack(); err != nil {
+				t.Error(err)
+			}
+			return StatusBadRequest
+		})
+
+		w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+		r := httptest.NewRequest("GET", "/", nil)
+		h.ServeHTTP(w, r)
+		if !w.hijacked || committed != StatusBadRequest {
+			t.Fatal(w.hijacked, committed)
+		} else if w.Body.Len() != 0 {
+			t.Fatal(w.Body.String())
+		}
+	})
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
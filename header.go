@@ -0,0 +1,83 @@
+package hproblem
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type headerError struct {
+	error
+	header http.Header
+}
+
+// Header implements the interface used by ServeError to set response headers.
+func (err *headerError) Header() http.Header { return err.header }
+
+func (err *headerError) Unwrap() error { return err.error }
+
+// WithHeader returns an error that wraps err and sets
+// the header field key to value in the response rendered by ServeError.
+func WithHeader(err error, key, value string) error {
+	header := make(http.Header, 1)
+	header.Set(key, value)
+	return &headerError{err, header}
+}
+
+// WithRetryAfter returns an error that wraps err and sets
+// the Retry-After header field to d rounded up to whole seconds.
+// Use it with 503 Service Unavailable, 429 Too Many Requests and
+// 3xx Redirection responses.
+func WithRetryAfter(err error, d time.Duration) error {
+	seconds := (d + time.Second - 1) / time.Second
+	if seconds < 0 {
+		seconds = 0
+	}
+	return WithHeader(err, "Retry-After", strconv.FormatInt(int64(seconds), 10))
+}
+
+// WithAuthenticate returns an error that wraps err and adds
+// a WWW-Authenticate header field for each challenge,
+// as required for 401 Unauthorized responses.
+func WithAuthenticate(err error, challenges ...string) error {
+	return &headerError{err, http.Header{"Www-Authenticate": challenges}}
+}
+
+// WithAllow returns an error that wraps err and sets
+// the Allow header field to the methods,
+// as required for 405 Method Not Allowed responses.
+func WithAllow(err error, methods ...string) error {
+	return WithHeader(err, "Allow", strings.Join(methods, ", "))
+}
+
+// MethodNotAllowedHandler returns a handler that replies to each request
+// with StatusMethodNotAllowed and the Allow header field set to the methods.
+func MethodNotAllowedHandler(methods ...string) http.Handler {
+	err := WithAllow(StatusMethodNotAllowed, methods...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeError(w, r, err)
+	})
+}
+
+// headerOf merges the header fields of all errors in the chain of err
+// that implement the Header() http.Header method.
+// Fields of outer errors take precedence over those of inner errors.
+func headerOf(err error) http.Header {
+	var header http.Header
+
+	walkChain(err, func(err error) {
+		if h, ok := err.(interface{ Header() http.Header }); ok { //nolint
+			for k, v := range h.Header() {
+				if _, ok := header[k]; !ok {
+					if header == nil {
+						header = make(http.Header)
+					}
+					header[k] = v
+				}
+			}
+		}
+	})
+
+	return header
+}
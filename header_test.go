@@ -0,0 +1,54 @@
+package hproblem
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHeader(t *testing.T) {
+	t.Run("RetryAfter", func(t *testing.T) {
+		err := fmt.Errorf("quota: %w", WithRetryAfter(StatusTooManyRequests, 1500*time.Millisecond))
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		ServeError(w, r, err)
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+			t.Fatal(w.Code, w.Header())
+		}
+	})
+
+	t.Run("Authenticate", func(t *testing.T) {
+		err := WithAuthenticate(StatusUnauthorized, `Basic realm="api"`, `Bearer realm="api"`)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		ServeError(w, r, err)
+		if v := w.Header().Values("WWW-Authenticate"); len(v) != 2 || v[1] != `Bearer realm="api"` {
+			t.Fatal(w.Header())
+		}
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		err := WithHeader(errors.Join(
+			WithHeader(StatusServiceUnavailable, "Retry-After", "10"),
+			WithHeader(StatusServiceUnavailable, "X-Inner", "a"),
+		), "Retry-After", "20")
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		ServeError(w, r, err)
+		if w.Header().Get("Retry-After") != "20" || w.Header().Get("X-Inner") != "a" {
+			t.Fatal(w.Header())
+		}
+	})
+
+	t.Run("MethodNotAllowedHandler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", nil)
+		MethodNotAllowedHandler(http.MethodGet, http.MethodHead).ServeHTTP(w, r)
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD" {
+			t.Fatal(w.Code, w.Header())
+		}
+	})
+}
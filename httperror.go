@@ -7,13 +7,9 @@
 package hproblem
 
 import (
-	"encoding/json"
 	"encoding/xml"
-	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"path"
 )
 
 type httpError struct {
@@ -29,44 +25,46 @@ func (err *httpError) Unwrap() error {
 	return err.error
 }
 
+func (err *httpError) Is(target error) bool {
+	return isProblem(target, err.statusCode, "")
+}
+
+// MarshalJSON redacts err like NewDetailsError does,
+// without assigning an instance or reporting it.
 func (err *httpError) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Detail string `json:"detail"`
-		Status int    `json:"status"`
-		Title  string `json:"title"`
-	}{
-		Detail: err.Error(),
-		Status: err.statusCode,
-		Title:  http.StatusText(err.statusCode),
-	})
+	return marshalJSON(newDetailsError(err, &occurrence{quiet: true}))
 }
 
+// MarshalXML redacts err like NewDetailsError does,
+// without assigning an instance or reporting it.
 func (err *httpError) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-	return e.Encode(struct {
-		Detail  string   `xml:"detail"`
-		Status  int      `xml:"status"`
-		Title   string   `xml:"title"`
-		XMLName xml.Name `xml:"urn:ietf:rfc:7807 problem"`
-	}{
-		Detail: err.Error(),
-		Status: err.statusCode,
-		Title:  http.StatusText(err.statusCode),
-	})
-}
+	p := newDetailsError(err, &occurrence{quiet: true})
 
-func serveJSON(w http.ResponseWriter, v interface{}, statusCode int) {
-	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.WriteHeader(statusCode)
-	_ = json.NewEncoder(w).Encode(v)
-}
+	start = xml.StartElement{Name: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, m := range []member{
+		{"detail", p.Detail},
+		{"instance", p.Instance},
+		{"status", p.Status},
+		{"title", p.Title},
+		{"type", p.Type},
+	} {
+		if m.value == "" || m.value == 0 {
+			continue
+		}
+		if err := encodeXMLMember(e, m.name, m.value); err != nil {
+			return err
+		}
+	}
 
-func serveXML(w http.ResponseWriter, v interface{}, statusCode int) {
-	w.Header().Set("Content-Type", "application/problem+xml; charset=utf-8")
-	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.WriteHeader(statusCode)
-	_, _ = io.WriteString(w, xml.Header)
-	_ = xml.NewEncoder(w).Encode(v)
+	if err := encodeXMLMembers(e, p.Extensions); err != nil {
+		return err
+	}
+
+	return e.EncodeToken(start.End())
 }
 
 // Wrap associates an error with a status code.
@@ -80,55 +78,29 @@ func Errorf(statusCode int, format string, a ...interface{}) error {
 }
 
 // StatusCode reports the HTTP status code associated with err
-// if it implements the StatusCode() int method,
+// by the first registered Classifier that recognizes it,
+// if it implements the StatusCode() int method and it does not return zero,
+// the status code registered for its type by RegisterType,
 // 504 Gateway Timeout if it implements Timeout() bool,
 // 503 Service Unavailable if it implements Temporary() bool,
 // 500 Internal Server Error otherwise, or 200 OK if err is nil.
 // StatusCode will unwrap err to find the most precise status code.
+// The status codes of the errors joined by errors.Join,
+// or any error that implements Unwrap() []error,
+// are combined by DefaultStatusPolicy.
+// Joined errors without a status code count as 500 Internal Server Error.
 func StatusCode(err error) int {
-	if err == nil {
-		return http.StatusOK
-	}
-
-	for ; err != nil; err = errors.Unwrap(err) {
-		if sc, ok := err.(interface{ StatusCode() int }); ok { //nolint
-			return sc.StatusCode()
-		} else if to, ok := err.(interface{ Timeout() bool }); ok && to.Timeout() { //nolint
-			return http.StatusGatewayTimeout
-		} else if te, ok := err.(interface{ Temporary() bool }); ok && te.Temporary() { //nolint
-			return http.StatusServiceUnavailable
-		}
-	}
-
-	return http.StatusInternalServerError
+	return DefaultStatusPolicy.StatusCode(err)
 }
 
-// ServeError replies to the request by rendering err.
+// ServeError replies to the request by rendering err with DefaultRenderer.
 // If err implements http.Handler, its ServeHTTP method is called.
 // Otherwise, err is rendered as JSON, XML or plain text depending on the
-// request's Accept header.
+// request's Accept header as determined by Negotiate.
+// Plain text is served if none of the formats are acceptable.
 // If err is nil, it will be rendered as StatusOK.
 func ServeError(w http.ResponseWriter, r *http.Request, err error) {
-	if err == nil {
-		err = StatusOK
-	}
-
-	if h, ok := err.(http.Handler); ok { //nolint
-		h.ServeHTTP(w, r)
-		return
-	}
-
-	for _, accept := range r.Header["Accept"] {
-		if ok, _ := path.Match("*/*json", accept); ok {
-			serveJSON(w, err, StatusCode(err))
-			return
-		} else if ok, _ := path.Match("*/*xml", accept); ok {
-			serveXML(w, err, StatusCode(err))
-			return
-		}
-	}
-
-	http.Error(w, err.Error(), StatusCode(err))
+	DefaultRenderer.ServeError(w, r, err)
 }
 
 // MethodNotAllowed replies to the request with StatusMethodNotAllowed.
@@ -51,6 +51,16 @@ func TestServeError(t *testing.T) {
 		}
 	})
 
+	t.Run("Negotiate", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "text/html, application/json;q=0.9")
+		NotFound(w, r)
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/problem+json") {
+			t.Fatal(w.Header().Get("Content-Type"))
+		}
+	})
+
 	t.Run("Text", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest("GET", "/", nil)
@@ -143,4 +153,17 @@ func TestErrorMarshalXML(t *testing.T) {
 	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/problem+xml") {
 		t.Fatal()
 	}
+
+	t.Run("extensions", func(t *testing.T) {
+		err := Wrap(http.StatusBadRequest, Decorate(errors.Join(
+			Errorf(http.StatusBadRequest, "name is required"),
+			Errorf(http.StatusBadRequest, "age must be positive"),
+		), WithExtension("field", "name")))
+		b, xerr := xml.Marshal(err)
+		if xerr != nil {
+			t.Fatal(xerr)
+		} else if s := string(b); s != `<problem xmlns="urn:ietf:rfc:7807"><detail>name is required`+"\n"+`age must be positive</detail><status>400</status><title>Bad Request</title><errors><i><detail>name is required</detail><status>400</status><title>Bad Request</title></i><i><detail>age must be positive</detail><status>400</status><title>Bad Request</title></i></errors><field>name</field></problem>` {
+			t.Fatal(s)
+		}
+	})
 }
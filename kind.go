@@ -0,0 +1,56 @@
+package hproblem
+
+// Kind is a sentinel error that identifies a kind of problem by its type URI.
+// errors.Is reports whether an error matches a Kind if it is a DetailsError,
+// or embeds one, whose Type is the type URI, or if it was created by New
+// or Decorate with that type. This matches the problems decoded by FromResponse.
+//
+//	var ErrOutOfCredit = hproblem.Kind("https://example.com/probs/out-of-credit")
+//
+//	if errors.Is(err, ErrOutOfCredit) {
+//	    // ...
+//	}
+//
+// A Kind can also be returned as an error. It is rendered with its type URI
+// and the title and status code registered for it by RegisterType.
+type Kind string
+
+// Error implements the error interface and returns the registered title,
+// or the type URI if the Kind is not registered.
+func (k Kind) Error() string {
+	if pt := lookupTypeURI(string(k)); pt != nil && pt.title != "" {
+		return pt.title
+	}
+	return string(k)
+}
+
+// StatusCode implements the interface used by StatusCode and returns
+// the registered status code, or zero if the Kind is not registered.
+func (k Kind) StatusCode() int {
+	if pt := lookupTypeURI(string(k)); pt != nil {
+		return pt.status
+	}
+	return 0
+}
+
+func (k Kind) decorate(details *DetailsError) {
+	if details.Type == "" {
+		details.Type = string(k)
+	}
+	if pt := lookupTypeURI(string(k)); pt != nil && details.Title == "" {
+		details.Title = pt.title
+	}
+}
+
+// isProblem reports whether a problem with a status code and a type URI
+// matches target, which is a Status* error or a Kind.
+func isProblem(target error, statusCode int, typeURI string) bool {
+	switch t := target.(type) {
+	case statusError:
+		return statusCode != 0 && statusCode == int(t)
+	case Kind:
+		return typeURI != "" && typeURI == string(t)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+package hproblem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIs(t *testing.T) {
+	for _, err := range []error{
+		StatusNotFound,
+		Wrap(http.StatusNotFound, errors.New("no jedi")),
+		Errorf(http.StatusNotFound, "no jedi"),
+		New(http.StatusNotFound),
+		fmt.Errorf("lookup: %w", &DetailsError{Status: http.StatusNotFound}),
+	} {
+		if !errors.Is(err, StatusNotFound) {
+			t.Fatal(err)
+		} else if errors.Is(err, StatusGone) {
+			t.Fatal(err)
+		}
+	}
+
+	if errors.Is(&DetailsError{}, StatusOK) || errors.Is(Decorate(errors.New("x")), StatusOK) {
+		t.Fatal()
+	}
+}
+
+func TestKind(t *testing.T) {
+	const creditURI = "https://example.com/probs/out-of-credit"
+	errOutOfCredit := Kind(creditURI)
+
+	t.Run("decoded", func(t *testing.T) {
+		resp := &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{"Content-Type": {"application/problem+json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"type":"` + creditURI + `","status":403}`)),
+		}
+		err := FromResponse(resp)
+		if !errors.Is(err, errOutOfCredit) || !errors.Is(err, StatusForbidden) {
+			t.Fatal(err)
+		} else if errors.Is(err, Kind("https://example.com/probs/other")) {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("new", func(t *testing.T) {
+		err := New(http.StatusForbidden, WithType(creditURI))
+		if !errors.Is(err, errOutOfCredit) {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		if errOutOfCredit.Error() != "You do not have enough credit." || StatusCode(errOutOfCredit) != http.StatusForbidden {
+			t.Fatal(errOutOfCredit.Error())
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		ServeError(w, r, fmt.Errorf("charge: %w", errOutOfCredit))
+		if b := w.Body.String(); b != `{"detail":"charge: You do not have enough credit.","status":403,"title":"You do not have enough credit.","type":"`+creditURI+`"}`+"\n" {
+			t.Fatal(b)
+		}
+	})
+
+	if Kind("urn:unregistered").Error() != "urn:unregistered" || Kind("urn:unregistered").StatusCode() != 0 {
+		t.Fatal()
+	}
+}
@@ -0,0 +1,142 @@
+package hproblem
+
+import "encoding/json"
+
+// AsDetails merges the layers in the chain of err into a new DetailsError,
+// so that wrapping an error never loses the members of the problem it describes.
+//
+// The chain is walked from err inwards, visiting the errors joined by
+// a multi-error in order. The layers are the DetailsErrors, the errors that
+// embed DetailsError, err itself if it implements json.Marshaler,
+// and the decorations added by New and Decorate.
+// Errors that implement json.Marshaler are not layers when wrapped,
+// since their encoding may hold details that are not meant for clients.
+// Members are merged as follows:
+//
+//   - Status is StatusCode(err), or 500 if it is out of the range 100-599.
+//   - Type, Title and Instance are taken from the first layer that sets them,
+//     then from the problem type registered for an error in the chain.
+//     Title is only taken from a layer that sets Type, or whose Status
+//     is either zero or the merged Status, since the title of another status
+//     would contradict it. Title defaults to the status text.
+//   - Each extension member is taken from the first layer that sets it.
+//   - Detail is decided by the outermost DetailsError, error embedding it,
+//     or error marked by Public, Private, PublicDetail or WithDetail,
+//     and is otherwise redacted like NewDetailsError does.
+//
+// If err joins multiple errors, the "errors" extension member
+// holds a []*DetailsError converted from each of them by AsDetails.
+func AsDetails(err error) *DetailsError {
+	return asDetails(err, nil)
+}
+
+func asDetails(err error, occ *occurrence) *DetailsError {
+	details := &DetailsError{
+		Status:       StatusCode(err),
+		wrappedError: err,
+	}
+
+	outermost := true
+	walkChain(err, func(err error) {
+		if d, ok := err.(interface{ decorate(*DetailsError) }); ok {
+			d.decorate(details)
+		}
+		if layer := layerOf(err, outermost); layer != nil {
+			mergeLayer(details, layer)
+		}
+		outermost = false
+	})
+
+	stampType(details, err)
+
+	completeDetails(details, err, occ, asDetails)
+	return details
+}
+
+// walkChain calls fn for every error in the chain of err,
+// including the errors joined by multi-errors, outermost first.
+func walkChain(err error, fn func(err error)) {
+	for ; err != nil; err = unwrap(err) {
+		fn(err)
+		if u, ok := err.(interface{ Unwrap() []error }); ok { //nolint
+			for _, err := range u.Unwrap() {
+				walkChain(err, fn)
+			}
+			return
+		}
+	}
+}
+
+// layerOf returns the problem document described by err itself,
+// or nil if err does not describe one.
+// Errors that implement json.Marshaler only describe one if outermost is set.
+func layerOf(err error, outermost bool) *DetailsError {
+	switch e := err.(type) {
+	case *DetailsError:
+		if e == nil {
+			return nil
+		}
+		return e
+	case statusError, *httpError:
+		// Their JSON encoding is derived from the rest of the chain.
+		return nil
+	case interface{ extensionMembers() map[string]interface{} }:
+		return marshalProblem(err)
+	case json.Marshaler:
+		if !outermost {
+			return nil
+		}
+		return marshalProblem(err)
+	default:
+		return nil
+	}
+}
+
+// marshalProblem converts err to a problem document by marshaling it to JSON.
+// It returns nil if err does not marshal to a JSON object.
+func marshalProblem(err error) *DetailsError {
+	b, jerr := json.Marshal(err)
+	if jerr != nil {
+		return nil
+	}
+
+	var p DetailsError
+	if p.Unmarshal(b) != nil {
+		return nil
+	}
+
+	for k, v := range extensionsOf(err) {
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]interface{})
+		}
+		if _, ok := p.Extensions[k]; !ok {
+			p.Extensions[k] = v
+		}
+	}
+
+	return &p
+}
+
+// mergeLayer fills in the empty Type, Title and Instance fields
+// and the missing extension members of details from layer.
+// The title of a layer without a type is only used
+// if the layer does not have a different status.
+func mergeLayer(details, layer *DetailsError) {
+	if details.Type == "" {
+		details.Type = layer.Type
+	}
+	if details.Title == "" && (layer.Type != "" || layer.Status == 0 || layer.Status == details.Status) {
+		details.Title = layer.Title
+	}
+	if details.Instance == "" {
+		details.Instance = layer.Instance
+	}
+	for name, value := range layer.Extensions {
+		if details.Extensions == nil {
+			details.Extensions = make(map[string]interface{})
+		}
+		if _, ok := details.Extensions[name]; !ok {
+			details.Extensions[name] = value
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package hproblem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAsDetails(t *testing.T) {
+	inner := &DetailsError{
+		Status:     http.StatusNotFound,
+		Type:       "https://example.com/probs/no-jedi",
+		Instance:   "/jedi/anakin",
+		Detail:     "This is not the Jedi that you are looking for",
+		Extensions: map[string]interface{}{"jedi": "anakin", "side": "light"},
+	}
+
+	t.Run("wrapped", func(t *testing.T) {
+		err := fmt.Errorf("lookup: %w", inner)
+		details := AsDetails(err)
+		if details.Status != http.StatusNotFound || details.Type != inner.Type || details.Instance != inner.Instance {
+			t.Fatal(details)
+		} else if details.Detail != inner.Detail || details.Title != "Not Found" {
+			t.Fatal(details)
+		} else if !errors.Is(details, inner) {
+			t.Fatal()
+		} else if NewDetailsError(err).Type != "" {
+			t.Fatal()
+		}
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		err := Decorate(fmt.Errorf("lookup: %w", inner),
+			WithInstance("/jedi/vader"),
+			WithExtension("side", "dark"),
+		)
+		err = &DetailsError{Title: "Fallen Jedi", wrappedError: err}
+		details := AsDetails(err)
+		if details.Status != http.StatusNotFound || details.Title != "Fallen Jedi" || details.Instance != "/jedi/vader" {
+			t.Fatal(details)
+		} else if !reflect.DeepEqual(details.Extensions, map[string]interface{}{"jedi": "anakin", "side": "dark"}) {
+			t.Fatal(details.Extensions)
+		} else if len(inner.Extensions) != 2 || inner.Extensions["side"] != "light" {
+			t.Fatal(inner.Extensions)
+		}
+	})
+
+	t.Run("title", func(t *testing.T) {
+		err := Wrap(http.StatusServiceUnavailable, &DetailsError{Status: http.StatusNotFound, Title: "Not Found"})
+		if details := AsDetails(err); details.Status != http.StatusServiceUnavailable || details.Title != "Service Unavailable" {
+			t.Fatal(details)
+		}
+
+		err = Wrap(http.StatusServiceUnavailable, &DetailsError{Status: http.StatusNotFound, Title: "No Jedi", Type: inner.Type})
+		if details := AsDetails(err); details.Status != http.StatusServiceUnavailable || details.Title != "No Jedi" {
+			t.Fatal(details)
+		}
+	})
+
+	t.Run("embedded", func(t *testing.T) {
+		err := fmt.Errorf("charge: %w", &testCreditError{
+			DetailsError: &DetailsError{Detail: "Your current balance is 30, but that costs 50."},
+			Balance:      30,
+		})
+		details := AsDetails(err)
+		if details.Status != http.StatusForbidden || details.Type != "https://example.com/probs/out-of-credit" {
+			t.Fatal(details)
+		} else if details.Detail != "Your current balance is 30, but that costs 50." {
+			t.Fatal(details.Detail)
+		} else if fmt.Sprint(details.Extensions["balance"]) != "30" {
+			t.Fatal(details.Extensions)
+		}
+	})
+
+	t.Run("joined", func(t *testing.T) {
+		err := errors.Join(StatusBadRequest, fmt.Errorf("lookup: %w", inner))
+		details := AsDetails(err)
+		if details.Status != http.StatusNotFound || details.Type != inner.Type {
+			t.Fatal(details)
+		} else if problems, _ := details.Extensions["errors"].([]*DetailsError); len(problems) != 2 || problems[1].Type != inner.Type {
+			t.Fatal(details.Extensions)
+		}
+	})
+
+	t.Run("marshaler", func(t *testing.T) {
+		err := &dbError{Query: "SELECT * FROM users", DSN: "postgres://admin:hunter2@db"}
+		if details := AsDetails(err); details.Extensions["dsn"] != err.DSN {
+			t.Fatal(details.Extensions)
+		}
+
+		for _, err := range []error{
+			fmt.Errorf("load user: %w", err),
+			Wrap(http.StatusInternalServerError, err),
+		} {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Accept", "application/json")
+			ServeError(w, r, err)
+			if b := w.Body.String(); strings.Contains(b, "dsn") || strings.Contains(b, "query") {
+				t.Fatal(b)
+			}
+		}
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		ServeError(w, r, fmt.Errorf("lookup: %w", inner))
+		if b := w.Body.String(); b != `{"detail":"This is not the Jedi that you are looking for","instance":"/jedi/anakin","status":404,"title":"Not Found","type":"https://example.com/probs/no-jedi","jedi":"anakin","side":"light"}`+"\n" {
+			t.Fatal(b)
+		}
+	})
+}
+
+type dbError struct {
+	Query string `json:"query"`
+	DSN   string `json:"dsn"`
+}
+
+func (err *dbError) Error() string { return "connection refused" }
+
+func (err *dbError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"query": err.Query, "dsn": err.DSN})
+}
@@ -0,0 +1,218 @@
+package hproblem
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is a single element of an Accept header.
+// See: https://www.rfc-editor.org/rfc/rfc9110#section-12.5.1
+type MediaRange struct {
+	// Type is the lowercase top-level type, or "*".
+	Type string
+
+	// Subtype is the lowercase subtype, or "*".
+	Subtype string
+
+	// Params holds the media type parameters that precede the weight.
+	// Parameter names are lowercase.
+	Params map[string]string
+
+	// Q is the weight in the range [0, 1]. It defaults to 1.
+	// A weight of 0 means "not acceptable".
+	Q float64
+}
+
+// ParseAccept parses one or more Accept header values into media ranges.
+// Elements that are not valid media ranges are skipped.
+func ParseAccept(values ...string) []MediaRange {
+	return appendAccept(nil, values)
+}
+
+// appendAccept appends the media ranges of the Accept header values to ranges.
+func appendAccept(ranges []MediaRange, values []string) []MediaRange {
+	for _, value := range values {
+		for value != "" {
+			var elem string
+			elem, value, _ = strings.Cut(value, ",")
+			if mr, ok := parseMediaRange(elem); ok {
+				ranges = append(ranges, mr)
+			}
+		}
+	}
+	return ranges
+}
+
+func parseMediaRange(s string) (MediaRange, bool) {
+	mediaType, rest, _ := strings.Cut(s, ";")
+	typ, subtype, ok := splitMediaType(mediaType)
+	if !ok || (typ == "*" && subtype != "*") {
+		return MediaRange{}, false
+	}
+
+	mr := MediaRange{Type: typ, Subtype: subtype, Q: 1}
+	for rest != "" {
+		var field string
+		field, rest, _ = strings.Cut(rest, ";")
+		name, value := splitParam(field)
+		if name == "" {
+			continue
+		} else if name == "q" {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				return MediaRange{}, false
+			}
+			// Parameters following the weight are accept-extensions.
+			mr.Q = q
+			break
+		}
+		if mr.Params == nil {
+			mr.Params = make(map[string]string)
+		}
+		mr.Params[name] = value
+	}
+
+	return mr, true
+}
+
+func splitMediaType(s string) (typ, subtype string, ok bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	i := strings.IndexByte(s, '/')
+	if i <= 0 || i == len(s)-1 {
+		return "", "", false
+	}
+	return s[:i], s[i+1:], true
+}
+
+func splitParam(s string) (name, value string) {
+	i := strings.IndexByte(s, '=')
+	if i < 0 {
+		return "", ""
+	}
+	name = strings.ToLower(strings.TrimSpace(s[:i]))
+	value = strings.Trim(strings.TrimSpace(s[i+1:]), `"`)
+	return name, value
+}
+
+// suffix returns the structured syntax suffix of subtype,
+// such as "json" for "problem+json".
+func suffix(subtype string) string {
+	if i := strings.LastIndexByte(subtype, '+'); i >= 0 {
+		return subtype[i+1:]
+	}
+	return ""
+}
+
+// match reports how specifically mr matches the offered media type,
+// or -1 if it does not match at all.
+//
+// A range of the same type whose subtype equals the structured syntax suffix
+// of the offer also matches, so that application/json and application/xml
+// select application/problem+json and application/problem+xml respectively.
+// So does text/xml, which RFC 7303 makes an alias of application/xml.
+func (mr MediaRange) match(typ, subtype string, params map[string]string) int {
+	var specificity int
+	switch {
+	case mr.Type == "*":
+		specificity = 1
+	case mr.Subtype == "*":
+		if mr.Type != typ {
+			return -1
+		}
+		specificity = 2
+	case mr.Type == typ && mr.Subtype == subtype:
+		specificity = 4
+	case mr.Subtype == suffix(subtype) && (mr.Type == typ || mr.Type == "text" && mr.Subtype == "xml"):
+		specificity = 3
+	default:
+		return -1
+	}
+
+	// Parameters are only compared if the offer declares them.
+	for name, value := range mr.Params {
+		if offered, ok := params[name]; ok {
+			if !strings.EqualFold(offered, value) {
+				return -1
+			}
+			specificity++
+		}
+	}
+
+	return specificity
+}
+
+// Negotiate returns the offer that best matches the Accept header of r
+// according to RFC 9110, Section 12.5.1.
+// Offers are media types optionally followed by parameters
+// and must be listed in order of preference.
+// The weight of an offer is that of the most specific media range
+// matching it. Ties are broken by specificity and then by order.
+// Negotiate returns the first offer if r has no valid Accept header,
+// or the empty string if no offer is acceptable.
+func Negotiate(r *http.Request, offers ...string) string {
+	return negotiate(r, parseOffers(offers))
+}
+
+// offer is a parsed offer of Negotiate.
+type offer struct {
+	value   string
+	typ     string
+	subtype string
+	params  map[string]string
+}
+
+// parseOffers parses the offers of Negotiate, skipping invalid ones.
+func parseOffers(values []string) []offer {
+	offers := make([]offer, 0, len(values))
+	for _, value := range values {
+		mediaType, rest, _ := strings.Cut(value, ";")
+		typ, subtype, ok := splitMediaType(mediaType)
+		if !ok {
+			continue
+		}
+
+		o := offer{value: value, typ: typ, subtype: subtype}
+		for rest != "" {
+			var field string
+			field, rest, _ = strings.Cut(rest, ";")
+			if name, value := splitParam(field); name != "" {
+				if o.params == nil {
+					o.params = make(map[string]string)
+				}
+				o.params[name] = value
+			}
+		}
+		offers = append(offers, o)
+	}
+	return offers
+}
+
+func negotiate(r *http.Request, offers []offer) string {
+	// Most Accept headers fit in buf, which does not escape.
+	var buf [8]MediaRange
+	ranges := appendAccept(buf[:0], r.Header.Values("Accept"))
+	if len(ranges) == 0 {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0].value
+	}
+
+	var best string
+	bestQ, bestSpecificity := 0.0, -1
+	for _, o := range offers {
+		q, specificity := 0.0, -1
+		for _, mr := range ranges {
+			if s := mr.match(o.typ, o.subtype, o.params); s > specificity {
+				q, specificity = mr.Q, s
+			}
+		}
+
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = o.value, q, specificity
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,61 @@
+package hproblem
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	ranges := ParseAccept(`text/html;level=1;q=0.5;ext=x, application/JSON`, "bogus, */json, */*;q=2")
+	if len(ranges) != 2 {
+		t.Fatal(ranges)
+	}
+	if r := ranges[0]; r.Type != "text" || r.Subtype != "html" || r.Q != 0.5 || len(r.Params) != 1 || r.Params["level"] != "1" {
+		t.Fatal(r)
+	}
+	if r := ranges[1]; r.Type != "application" || r.Subtype != "json" || r.Q != 1 {
+		t.Fatal(r)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"text/plain", "application/problem+json", "application/problem+xml"}
+	for _, testCase := range []struct {
+		Accept   string
+		Expected string
+	}{
+		{"", "text/plain"},
+		{"*/*", "text/plain"},
+		{"application/json", "application/problem+json"},
+		{"text/xml", "application/problem+xml"},
+		{"application/problem+xml", "application/problem+xml"},
+		{"text/html, application/json;q=0.9", "application/problem+json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "application/problem+xml"},
+		{"application/json, */*", "application/problem+json"},
+		{"application/*;q=0.5, text/plain;q=0.4", "application/problem+json"},
+		{"*/*, text/plain;q=0", "application/problem+json"},
+		{"application/json;q=0, application/*", "application/problem+xml"},
+		{"image/png", ""},
+		{"text/json", ""},
+		{"foo/json, foo/xml", ""},
+		{"*/*;q=0", ""},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		if testCase.Accept != "" {
+			r.Header.Set("Accept", testCase.Accept)
+		}
+		if offer := Negotiate(r, offers...); offer != testCase.Expected {
+			t.Error(testCase.Accept, offer, testCase.Expected)
+		}
+	}
+
+	t.Run("params", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Add("Accept", "text/plain;charset=iso-8859-1")
+		r.Header.Add("Accept", "application/json;charset=UTF-8;q=0.5")
+		offer := Negotiate(r, "text/plain; charset=utf-8", "application/problem+json; charset=utf-8")
+		if offer != "application/problem+json; charset=utf-8" {
+			t.Fatal(offer)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package hproblem
+
+// Option sets a member of a problem created by New or Decorate.
+type Option func(*problemError)
+
+// WithType sets the Type member.
+func WithType(typeURI string) Option {
+	return func(err *problemError) { err.typ = typeURI }
+}
+
+// WithTitle sets the Title member.
+func WithTitle(title string) Option {
+	return func(err *problemError) { err.title = title }
+}
+
+// WithInstance sets the Instance member.
+func WithInstance(instance string) Option {
+	return func(err *problemError) { err.instance = instance }
+}
+
+// WithDetail sets the Detail member. The detail is public,
+// as if set by PublicDetail, and prefixes the error message.
+func WithDetail(detail string) Option {
+	return func(err *problemError) { err.detail = detail }
+}
+
+// WithExtension sets an extension member.
+func WithExtension(name string, value interface{}) Option {
+	return func(err *problemError) {
+		if err.extensions == nil {
+			err.extensions = make(map[string]interface{})
+		}
+		err.extensions[name] = value
+	}
+}
+
+// WithCause sets the error that the problem wraps.
+// It replaces the error passed to Decorate.
+func WithCause(cause error) Option {
+	return func(err *problemError) { err.cause = cause }
+}
+
+// New returns an error with a status code and the members set by opts.
+//
+//	err := hproblem.New(http.StatusNotFound,
+//	    hproblem.WithType("https://example.com/probs/no-jedi"),
+//	    hproblem.WithDetail("This is not the Jedi that you are looking for"),
+//	)
+func New(statusCode int, opts ...Option) error {
+	return newProblem(&problemError{statusCode: statusCode}, opts)
+}
+
+// Decorate returns an error that wraps err and sets the members set by opts.
+// The status code of err is retained.
+// NewDetailsError and ServeError merge the members of all decorations
+// in the chain of an error. Outer decorations take precedence.
+func Decorate(err error, opts ...Option) error {
+	return newProblem(&problemError{cause: err}, opts)
+}
+
+func newProblem(err *problemError, opts []Option) error {
+	for _, opt := range opts {
+		opt(err)
+	}
+	if err.detail != "" {
+		return detailedProblemError{err}
+	}
+	return err
+}
+
+type problemError struct {
+	cause      error
+	statusCode int
+	detail     string
+	typ        string
+	title      string
+	instance   string
+	extensions map[string]interface{}
+}
+
+func (err *problemError) Error() string {
+	switch {
+	case err.cause != nil && err.detail != "":
+		return err.detail + ": " + err.cause.Error()
+	case err.cause != nil:
+		return err.cause.Error()
+	case err.detail != "":
+		return err.detail
+	default:
+		return StatusText(err.statusCode)
+	}
+}
+
+func (err *problemError) StatusCode() int { return err.statusCode }
+
+func (err *problemError) Unwrap() error { return err.cause }
+
+func (err *problemError) Is(target error) bool {
+	return isProblem(target, err.statusCode, err.typ)
+}
+
+// decorate fills in the empty members of details.
+func (err *problemError) decorate(details *DetailsError) {
+	if details.Type == "" {
+		details.Type = err.typ
+	}
+	if details.Title == "" {
+		details.Title = err.title
+	}
+	if details.Instance == "" {
+		details.Instance = err.instance
+	}
+	for name, value := range err.extensions {
+		if details.Extensions == nil {
+			details.Extensions = make(map[string]interface{})
+		}
+		if _, ok := details.Extensions[name]; !ok {
+			details.Extensions[name] = value
+		}
+	}
+}
+
+type detailedProblemError struct{ *problemError }
+
+func (err detailedProblemError) publicDetail() (string, bool) { return err.detail, true }
+
+// decorate fills in the empty members of details
+// from the decorations in the chain of err.
+func decorate(details *DetailsError, err error) {
+	for ; err != nil; err = unwrap(err) {
+		if d, ok := err.(interface{ decorate(*DetailsError) }); ok {
+			d.decorate(details)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package hproblem
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	errNoRows := errors.New("no rows")
+
+	// A library deep in the stack sets the type.
+	err := New(http.StatusNotFound,
+		WithType("https://example.com/probs/no-jedi"),
+		WithTitle("Jedi not found"),
+		WithDetail("This is not the Jedi that you are looking for"),
+		WithExtension("jedi", "obi-wan"),
+		WithCause(errNoRows),
+	)
+
+	// An outer layer adds the instance and overrides an extension.
+	err = Decorate(fmt.Errorf("handler: %w", err),
+		WithInstance("/jedi/obi-wan"),
+		WithExtension("jedi", "ben"),
+	)
+
+	if StatusCode(err) != http.StatusNotFound {
+		t.Fatal(StatusCode(err))
+	} else if !errors.Is(err, errNoRows) {
+		t.Fatal()
+	} else if err.Error() != "handler: This is not the Jedi that you are looking for: no rows" {
+		t.Fatal(err.Error())
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/json")
+	ServeError(w, r, err)
+	if b := w.Body.String(); b != `{"detail":"This is not the Jedi that you are looking for","instance":"/jedi/obi-wan","status":404,"title":"Jedi not found","type":"https://example.com/probs/no-jedi","jedi":"ben"}`+"\n" {
+		t.Fatal(b)
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		err := New(http.StatusConflict)
+		details := NewDetailsError(err)
+		if err.Error() != "Conflict" || details.Detail != "Conflict" || details.Status != http.StatusConflict {
+			t.Fatal(details)
+		}
+	})
+
+	t.Run("private cause", func(t *testing.T) {
+		err := Decorate(errors.New("secret"), WithType("https://example.com/probs/db"))
+		details := NewDetailsError(err)
+		if details.Status != http.StatusInternalServerError || details.Detail != "Internal Server Error" || details.Type != "https://example.com/probs/db" {
+			t.Fatal(details)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package hproblem
+
+import "net/http"
+
+// StatusPolicy combines the status codes of the errors joined by a multi-error
+// into one. The status codes are listed in order and there is at least one.
+type StatusPolicy func(codes []int) int
+
+// DefaultStatusPolicy is the StatusPolicy used by the StatusCode function.
+var DefaultStatusPolicy StatusPolicy = MostSevereStatus
+
+// FirstStatus selects the first status code.
+func FirstStatus(codes []int) int {
+	return codes[0]
+}
+
+// MostSevereStatus selects the highest status code,
+// so that server errors take precedence over client errors.
+func MostSevereStatus(codes []int) int {
+	max := codes[0]
+	for _, code := range codes[1:] {
+		if code > max {
+			max = code
+		}
+	}
+	return max
+}
+
+// ClientErrorStatus selects the first 4xx status code,
+// or the highest status code if there are none.
+// It is suitable when client errors, such as validation failures,
+// make any server errors irrelevant.
+func ClientErrorStatus(codes []int) int {
+	for _, code := range codes {
+		if code >= 400 && code < 500 {
+			return code
+		}
+	}
+	return MostSevereStatus(codes)
+}
+
+// StatusCode is like the StatusCode function
+// but combines the status codes of multi-errors by p.
+// Joined errors that carry no status code count as
+// 500 Internal Server Error, as they would on their own.
+func (p StatusPolicy) StatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	} else if code, ok := p.statusCode(err); ok {
+		return code
+	}
+	return http.StatusInternalServerError
+}
+
+func (p StatusPolicy) statusCode(err error) (int, bool) {
+	for err != nil {
+		if code, ok := classify(err); ok {
+			return code, true
+		} else if sc, ok := err.(interface{ StatusCode() int }); ok && sc.StatusCode() != 0 { //nolint
+			return sc.StatusCode(), true
+		} else if pt := lookupGoType(err); pt != nil && pt.status != 0 {
+			return pt.status, true
+		} else if to, ok := err.(interface{ Timeout() bool }); ok && to.Timeout() { //nolint
+			return http.StatusGatewayTimeout, true
+		} else if te, ok := err.(interface{ Temporary() bool }); ok && te.Temporary() { //nolint
+			return http.StatusServiceUnavailable, true
+		}
+
+		switch u := err.(type) { //nolint
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			var codes []int
+			for _, err := range u.Unwrap() {
+				if err == nil {
+					continue
+				}
+				code, ok := p.statusCode(err)
+				if !ok {
+					code = http.StatusInternalServerError
+				}
+				codes = append(codes, code)
+			}
+			if len(codes) == 0 {
+				return 0, false
+			}
+			return p(codes), true
+		default:
+			return 0, false
+		}
+	}
+
+	return 0, false
+}
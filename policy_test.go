@@ -0,0 +1,47 @@
+package hproblem
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type multiError []error
+
+func (errs multiError) Error() string   { return "multi" }
+func (errs multiError) Unwrap() []error { return errs }
+
+func TestStatusPolicy(t *testing.T) {
+	joined := errors.Join(
+		errors.New("no status"),
+		Wrap(http.StatusNotFound, errors.New("a")),
+		Wrap(http.StatusBadGateway, errors.New("b")),
+		Wrap(http.StatusBadRequest, errors.New("c")),
+	)
+
+	for _, testCase := range []struct {
+		Policy   StatusPolicy
+		Err      error
+		Expected int
+	}{
+		{FirstStatus, joined, http.StatusInternalServerError},
+		{MostSevereStatus, joined, http.StatusBadGateway},
+		{ClientErrorStatus, joined, http.StatusNotFound},
+		{ClientErrorStatus, errors.Join(StatusBadGateway, StatusServiceUnavailable), http.StatusServiceUnavailable},
+		{MostSevereStatus, fmt.Errorf("wrapped: %w", joined), http.StatusBadGateway},
+		{FirstStatus, Wrap(http.StatusConflict, joined), http.StatusConflict},
+		{FirstStatus, multiError{errors.New("a"), errors.New("b")}, http.StatusInternalServerError},
+		{ClientErrorStatus, multiError{errors.New("a"), multiError{StatusGone}}, http.StatusGone},
+		{MostSevereStatus, errors.Join(Wrap(http.StatusBadRequest, errors.New("x")), errors.New("db down")), http.StatusInternalServerError},
+		{FirstStatus, nil, http.StatusOK},
+	} {
+		if code := testCase.Policy.StatusCode(testCase.Err); code != testCase.Expected {
+			t.Error(testCase.Err, code, testCase.Expected)
+		}
+	}
+
+	if StatusCode(joined) != http.StatusBadGateway {
+		t.Fatal()
+	}
+}
@@ -0,0 +1,100 @@
+package hproblem
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is the error that Recover renders for a recovered panic.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+
+	// Instance is the unique identifier of the occurrence,
+	// which is the instance of the problem that Recover renders.
+	Instance string
+}
+
+// Error implements the error interface.
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", err.Value)
+}
+
+// StatusCode implements the interface used by StatusCode
+// and returns StatusInternalServerError.
+func (err *PanicError) StatusCode() int {
+	return http.StatusInternalServerError
+}
+
+// Unwrap returns Value if it is an error.
+func (err *PanicError) Unwrap() error {
+	e, _ := err.Value.(error)
+	return e
+}
+
+// Recover returns middleware that recovers from panics in next
+// and renders them with DefaultRenderer.
+// See Renderer.Recover.
+func Recover(next http.Handler) http.Handler {
+	return DefaultRenderer.Recover(next)
+}
+
+// Recover returns middleware that recovers from panics in next
+// and renders them with rr as StatusInternalServerError.
+// The panic is reported to rr.OnPanic first.
+// The problem and the PanicError share a unique instance identifier,
+// so that the response can be correlated with the report.
+// If rr.Debug is set, the problem details the panic value
+// and includes the stack trace in the "stack" extension member.
+//
+// http.ErrAbortHandler is panicked again, as is any panic
+// after next has written the response headers, in which case
+// it is replaced by http.ErrAbortHandler to abort the response.
+func (rr *Renderer) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			} else if v == http.ErrAbortHandler { //nolint
+				panic(v)
+			}
+
+			err := &PanicError{Value: v, Stack: debug.Stack(), Instance: newOccurrence()}
+			if rr.OnPanic != nil {
+				rr.OnPanic(r, err)
+			} else {
+				log.Printf("hproblem: %s %s: %s: %v\n%s", r.Method, r.URL.Path, err.Instance, err, err.Stack)
+			}
+
+			if rw.committed {
+				panic(http.ErrAbortHandler)
+			}
+
+			p := &DetailsError{
+				Instance:     err.Instance,
+				Status:       http.StatusInternalServerError,
+				wrappedError: err,
+			}
+
+			if rr.Debug {
+				p.Detail = err.Error()
+				p.Extensions = map[string]interface{}{
+					"stack": string(err.Stack),
+				}
+			}
+
+			clearContentHeader(w.Header())
+			rr.ServeError(w, r, p)
+		}()
+
+		next.ServeHTTP(rw.expose(), r)
+	})
+}
@@ -0,0 +1,67 @@
+package hproblem
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var recovered *PanicError
+	rr := NewRenderer()
+	rr.OnPanic = func(r *http.Request, err *PanicError) {
+		recovered = err
+	}
+
+	errBoom := errors.New("boom")
+	h := rr.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/abort" {
+			panic(http.ErrAbortHandler)
+		} else if r.URL.Path == "/committed" {
+			w.WriteHeader(http.StatusOK)
+		}
+		panic(errBoom)
+	}))
+
+	t.Run("panic", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusInternalServerError {
+			t.Fatal(w.Code)
+		} else if !errors.Is(recovered, errBoom) || len(recovered.Stack) == 0 || !strings.HasPrefix(recovered.Instance, "urn:uuid:") {
+			t.Fatal(recovered)
+		} else if b := w.Body.String(); b != `{"instance":"`+recovered.Instance+`","status":500,"title":"Internal Server Error"}`+"\n" {
+			t.Fatal(b)
+		}
+	})
+
+	t.Run("debug", func(t *testing.T) {
+		rr.Debug = true
+		defer func() { rr.Debug = false }()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		h.ServeHTTP(w, r)
+		if b := w.Body.String(); !strings.HasPrefix(b, `{"detail":"panic: boom","instance":"`+recovered.Instance+`","status":500,"title":"Internal Server Error","stack":"goroutine `) {
+			t.Fatal(b)
+		}
+	})
+
+	for _, path := range []string{"/abort", "/committed"} {
+		t.Run(path, func(t *testing.T) {
+			defer func() {
+				if v := recover(); v != http.ErrAbortHandler {
+					t.Fatal(v)
+				}
+			}()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", path, nil)
+			h.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package hproblem
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// OnRedact, if not nil, is called by NewDetailsError with the original error
+// and the occurrence identifier that replaces its message.
+// The error is logged by the log package if it is nil.
+// It must be set before serving, since it is not safe to change concurrently.
+// Use Renderer.OnRedact to route the reports of a Renderer elsewhere.
+var OnRedact func(instance string, err error)
+
+// detailer is implemented by errors that decide which detail reaches clients.
+type detailer interface {
+	publicDetail() (detail string, public bool)
+}
+
+type publicError struct{ error }
+
+func (err *publicError) Unwrap() error { return err.error }
+
+func (err *publicError) publicDetail() (string, bool) { return err.Error(), true }
+
+type privateError struct{ error }
+
+func (err *privateError) Unwrap() error { return err.error }
+
+func (err *privateError) publicDetail() (string, bool) { return "", false }
+
+type publicDetailError struct {
+	error
+	detail string
+}
+
+func (err *publicDetailError) Unwrap() error { return err.error }
+
+func (err *publicDetailError) publicDetail() (string, bool) { return err.detail, true }
+
+// Public marks the message of err as safe to expose to clients,
+// even if err is a server error.
+func Public(err error) error {
+	return &publicError{err}
+}
+
+// Private marks the message of err as unsafe to expose to clients,
+// even if err is a client error.
+func Private(err error) error {
+	return &privateError{err}
+}
+
+// PublicDetail returns an error that exposes detail to clients
+// in place of the message of err, which remains private.
+// The returned error reports the message of err
+// so that it can be logged in full.
+func PublicDetail(err error, detail string) error {
+	return &publicDetailError{err, detail}
+}
+
+// redact returns the detail of err that may be exposed to clients.
+// The outermost error in the chain of err marked by Public, Private
+// or PublicDetail decides. Otherwise, the detail of a multi-error
+// is made of the public details of the errors it joins,
+// and the message of err is public unless statusCode is a server error.
+func redact(err error, statusCode int) (detail string, redacted bool) {
+	for e := err; e != nil; e = unwrap(e) {
+		if d, ok := e.(detailer); ok {
+			if detail, public := d.publicDetail(); public {
+				return detail, false
+			}
+			return StatusText(statusCode), true
+		} else if u, ok := e.(interface{ Unwrap() []error }); ok { //nolint
+			return redactJoined(u.Unwrap(), statusCode)
+		}
+	}
+
+	if detail = err.Error(); statusCode >= 500 && detail != StatusText(statusCode) {
+		return StatusText(statusCode), true
+	}
+
+	return detail, false
+}
+
+// redactJoined returns the public details of errs separated by newlines,
+// or the status text of statusCode if any of them is redacted.
+func redactJoined(errs []error, statusCode int) (detail string, redacted bool) {
+	details := make([]string, 0, len(errs))
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		detail, redacted := redact(err, StatusCode(err))
+		if redacted {
+			return StatusText(statusCode), true
+		}
+		details = append(details, detail)
+	}
+	return strings.Join(details, "\n"), false
+}
+
+// occurrence identifies the occurrence of the redacted problems
+// converted from the same error.
+// A quiet occurrence is neither identified nor reported,
+// so that the conversion is deterministic.
+// Otherwise, it is reported to report, or to OnRedact if report is nil.
+type occurrence struct {
+	id      string
+	quiet   bool
+	started bool
+	report  func(instance string, err error)
+}
+
+// identify returns the Instance of a redacted problem and records it
+// as the occurrence identifier unless one is already recorded.
+// A new occurrence identifier is used if instance is empty.
+func (occ *occurrence) identify(instance string) string {
+	if occ.quiet {
+		return instance
+	}
+	if occ.id == "" {
+		if occ.id = instance; occ.id == "" {
+			occ.id = newOccurrence()
+		}
+	}
+	if instance == "" {
+		return occ.id
+	}
+	return instance
+}
+
+// newOccurrence returns a URN that uniquely identifies an occurrence of a problem.
+func newOccurrence() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// reportRedacted reports the redacted error err of the occurrence.
+func (occ *occurrence) reportRedacted(err error) {
+	if occ.report != nil {
+		occ.report(occ.id, err)
+	} else {
+		reportRedacted(occ.id, err)
+	}
+}
+
+func reportRedacted(instance string, err error) {
+	if OnRedact != nil {
+		OnRedact(instance, err)
+	} else {
+		log.Printf("hproblem: %s: %v", instance, err)
+	}
+}
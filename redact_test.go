@@ -0,0 +1,91 @@
+package hproblem
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	var reported []error
+	OnRedact = func(instance string, err error) {
+		if !strings.HasPrefix(instance, "urn:uuid:") || len(instance) != 45 {
+			t.Error(instance)
+		}
+		reported = append(reported, err)
+	}
+	defer func() { OnRedact = nil }()
+
+	errSecret := errors.New("dial tcp 10.0.0.1:5432: connection refused")
+
+	for _, testCase := range []struct {
+		Err      error
+		Detail   string
+		Redacted bool
+	}{
+		{fmt.Errorf("query: %w", errSecret), "Internal Server Error", true},
+		{Wrap(http.StatusBadGateway, errSecret), "Bad Gateway", true},
+		{StatusInternalServerError, "Internal Server Error", false},
+		{Errorf(http.StatusBadRequest, "name is required"), "name is required", false},
+		{Private(Errorf(http.StatusForbidden, "user 42 is banned")), "Forbidden", true},
+		{Public(Errorf(http.StatusServiceUnavailable, "down for maintenance")), "down for maintenance", false},
+		{fmt.Errorf("outer: %w", Public(Errorf(http.StatusServiceUnavailable, "down"))), "down", false},
+		{PublicDetail(Wrap(http.StatusServiceUnavailable, errSecret), "try again later"), "try again later", false},
+		{Private(Public(Wrap(http.StatusServiceUnavailable, errSecret))), "Service Unavailable", true},
+		{errors.Join(Errorf(http.StatusBadRequest, "bad name"), Errorf(http.StatusConflict, "taken")), "bad name\ntaken", false},
+		{errors.Join(Errorf(http.StatusBadRequest, "bad name"), PublicDetail(Wrap(http.StatusServiceUnavailable, errSecret), "try again later")), "bad name\ntry again later", false},
+	} {
+		reported = nil
+		details := NewDetailsError(testCase.Err)
+		if details.Detail != testCase.Detail {
+			t.Error(testCase.Err, details.Detail)
+		} else if redacted := details.Instance != ""; redacted != testCase.Redacted {
+			t.Error(testCase.Err, details.Instance)
+		} else if testCase.Redacted && (len(reported) != 1 || reported[0] != testCase.Err) {
+			t.Error(testCase.Err, reported)
+		}
+	}
+
+	t.Run("joined", func(t *testing.T) {
+		DefaultStatusPolicy = ClientErrorStatus
+		defer func() { DefaultStatusPolicy = MostSevereStatus }()
+
+		reported = nil
+		err := errors.Join(Errorf(http.StatusBadRequest, "bad name"), Wrap(http.StatusInternalServerError, errSecret))
+		details := AsDetails(err)
+		problems, _ := details.Extensions["errors"].([]*DetailsError)
+		if details.Status != http.StatusBadRequest || details.Detail != "Bad Request" {
+			t.Fatal(details)
+		} else if len(problems) != 2 || problems[0].Instance != "" || problems[1].Instance != details.Instance {
+			t.Fatal(problems)
+		} else if len(reported) != 1 || reported[0] != err {
+			t.Fatal(reported)
+		}
+
+		b, _ := marshalJSON(details)
+		if strings.Contains(string(b), "10.0.0.1") {
+			t.Fatal(string(b))
+		}
+	})
+
+	t.Run("MarshalJSON", func(t *testing.T) {
+		reported = nil
+		err := Wrap(http.StatusInternalServerError, errSecret)
+		b, jerr := json.Marshal(err)
+		if jerr != nil {
+			t.Fatal(jerr)
+		} else if strings.Contains(string(b), "10.0.0.1") {
+			t.Fatal(string(b))
+		} else if b2, _ := json.Marshal(err); string(b2) != string(b) {
+			t.Fatal(string(b), string(b2))
+		} else if x, _ := xml.Marshal(err); strings.Contains(string(x), "10.0.0.1") || strings.Contains(string(x), "instance") {
+			t.Fatal(string(x))
+		} else if len(reported) != 0 {
+			t.Fatal(reported)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package hproblem
+
+import (
+	"net/http"
+	"net/url"
+)
+
+type redirectError struct {
+	url        string
+	statusCode int
+}
+
+// Redirect returns an error that ServeError serves as a redirect to url
+// with the Location header field, like http.Redirect does.
+// The url may be a path relative to the request path.
+// The code should be in the 3xx range and is usually
+// StatusFound, StatusSeeOther, StatusTemporaryRedirect
+// or StatusPermanentRedirect.
+func Redirect(url string, code int) error {
+	return &redirectError{url, code}
+}
+
+func (err *redirectError) Error() string {
+	return "redirect to " + err.url
+}
+
+func (err *redirectError) StatusCode() int {
+	return err.statusCode
+}
+
+func (err *redirectError) Header() http.Header {
+	return http.Header{"Location": {err.url}}
+}
+
+func (err *redirectError) Is(target error) bool {
+	return isProblem(target, err.statusCode, "")
+}
+
+// resolveLocation resolves a relative Location header field of h
+// against the path of the request.
+func resolveLocation(h http.Header, r *http.Request) {
+	loc := h.Get("Location")
+	if loc == "" {
+		return
+	}
+
+	ref, err := url.Parse(loc)
+	if err != nil || ref.Scheme != "" || ref.Host != "" {
+		return
+	}
+
+	base := &url.URL{Path: r.URL.Path}
+	if base.Path == "" {
+		base.Path = "/"
+	}
+	h.Set("Location", base.ResolveReference(ref).String())
+}
@@ -0,0 +1,41 @@
+package hproblem
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	for _, test := range []struct {
+		Path     string
+		URL      string
+		Location string
+	}{
+		{"/jedi/obi-wan", "/login?next=%2Fjedi", "/login?next=%2Fjedi"},
+		{"/jedi/obi-wan", "anakin", "/jedi/anakin"},
+		{"/jedi/obi-wan", "../sith/vader", "/sith/vader"},
+		{"/jedi/obi-wan", "https://example.com/jedi", "https://example.com/jedi"},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", test.Path, nil)
+		err := fmt.Errorf("auth: %w", Redirect(test.URL, http.StatusSeeOther))
+		ServeError(w, r, err)
+		if w.Result().StatusCode != http.StatusSeeOther {
+			t.Fatal(w.Result().StatusCode)
+		} else if loc := w.Header().Get("Location"); loc != test.Location {
+			t.Fatal(loc)
+		} else if !errors.Is(err, StatusSeeOther) {
+			t.Fatal()
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	ServeError(w, r, Redirect("/jedi", http.StatusNotModified))
+	if w.Result().StatusCode != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("Location") != "/jedi" {
+		t.Fatal(w.Header())
+	}
+}
@@ -0,0 +1,181 @@
+package hproblem
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"sync"
+	"unicode"
+)
+
+type problemType struct {
+	uri    string
+	title  string
+	status int
+	goType reflect.Type
+}
+
+var registry struct {
+	sync.RWMutex
+	byURI    map[string]*problemType
+	byGoType map[reflect.Type]*problemType
+}
+
+var detailsErrorType = reflect.TypeOf((*DetailsError)(nil))
+
+// RegisterType registers a problem type identified by typeURI
+// with its default title and status code.
+// The prototype must be a pointer to a struct that embeds *DetailsError,
+// such as (*OutOfCreditError)(nil).
+//
+// Errors of the prototype's type are rendered with typeURI,
+// title and status unless their DetailsError sets them,
+// and StatusCode reports status for them.
+// FromResponse decodes problems of type typeURI into new values
+// of the prototype's type.
+//
+// Registering a type URI again replaces the previous registration.
+// RegisterType panics if the prototype is not valid.
+func RegisterType(typeURI, title string, status int, prototype error) {
+	t := reflect.TypeOf(prototype)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		panic("hproblem: prototype must be a pointer to a struct")
+	}
+
+	if f, ok := t.Elem().FieldByName("DetailsError"); !ok || !f.Anonymous || f.Type != detailsErrorType {
+		panic("hproblem: prototype must embed *DetailsError")
+	}
+
+	pt := &problemType{
+		uri:    typeURI,
+		title:  title,
+		status: status,
+		goType: t,
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.byURI == nil {
+		registry.byURI = make(map[string]*problemType)
+		registry.byGoType = make(map[reflect.Type]*problemType)
+	}
+
+	if old := registry.byURI[typeURI]; old != nil {
+		delete(registry.byGoType, old.goType)
+	}
+
+	registry.byURI[typeURI] = pt
+	registry.byGoType[t] = pt
+}
+
+func lookupTypeURI(typeURI string) *problemType {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.byURI[typeURI]
+}
+
+func lookupGoType(err error) *problemType {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.byGoType[reflect.TypeOf(err)]
+}
+
+// stampType fills in the Type, Title and Status fields of p
+// from the first registered type in the chain of err if they are empty.
+func stampType(p *DetailsError, err error) {
+	for ; err != nil; err = unwrap(err) {
+		if pt := lookupGoType(err); pt != nil {
+			if p.Type == "" {
+				p.Type = pt.uri
+			}
+			if p.Title == "" {
+				p.Title = pt.title
+			}
+			if p.Status == 0 {
+				p.Status = pt.status
+			}
+			return
+		}
+	}
+}
+
+func unwrap(err error) error {
+	if u, ok := err.(interface{ Unwrap() error }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// decodeProblem decodes the JSON or XML encoded problem in data.
+// If its type is registered, it returns a new value of the registered Go type
+// with the unknown members that are not fields of that type in Extensions.
+// Otherwise, it returns a *DetailsError.
+func decodeProblem(data []byte) (problem error, details *DetailsError, err error) {
+	data = bytes.TrimLeftFunc(data, unicode.IsSpace)
+
+	p := &DetailsError{}
+	if err := p.Unmarshal(data); err != nil {
+		return nil, nil, err
+	}
+
+	pt := lookupTypeURI(p.Type)
+	if pt == nil || p.Type == "" {
+		return p, p, nil
+	}
+
+	v := reflect.New(pt.goType.Elem())
+
+	if data[0] == '<' {
+		err = unmarshalXMLFields(data, v.Elem())
+	} else {
+		err = json.Unmarshal(data, v.Interface())
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v.Elem().FieldByName("DetailsError").Set(reflect.ValueOf(p))
+
+	// Remove the extension members that have become fields.
+	if b, err := json.Marshal(v.Interface()); err == nil {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(b, &fields) == nil {
+			for name := range fields {
+				delete(p.Extensions, name)
+			}
+		}
+		if len(p.Extensions) == 0 {
+			p.Extensions = nil
+		}
+	}
+
+	return v.Interface().(error), p, nil
+}
+
+// unmarshalXMLFields decodes the XML document data into the exported fields
+// of the struct v other than the embedded DetailsError.
+// It works around encoding/xml being unable to decode into structs
+// that embed a pointer to a struct with an XMLName field.
+func unmarshalXMLFields(data []byte, v reflect.Value) error {
+	var fields []reflect.StructField
+	var index []int
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Type().Field(i); f.PkgPath == "" && f.Type != detailsErrorType {
+			fields = append(fields, f)
+			index = append(index, i)
+		}
+	}
+
+	tmp := reflect.New(reflect.StructOf(fields))
+	if err := xml.Unmarshal(data, tmp.Interface()); err != nil {
+		return err
+	}
+
+	for i, j := range index {
+		v.Field(j).Set(tmp.Elem().Field(i))
+	}
+
+	return nil
+}
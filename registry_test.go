@@ -0,0 +1,105 @@
+package hproblem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testCreditError struct {
+	*DetailsError
+	Balance  int      `json:"balance" xml:"balance"`
+	Accounts []string `json:"accounts" xml:"accounts>i"`
+}
+
+func init() {
+	RegisterType("https://example.com/probs/out-of-credit", "You do not have enough credit.", http.StatusForbidden, (*testCreditError)(nil))
+}
+
+func TestRegisterType(t *testing.T) {
+	for _, prototype := range []error{nil, testEmbeddedDetails{}, &customError{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error(prototype)
+				}
+			}()
+			RegisterType("invalid", "", 0, prototype)
+		}()
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	err := fmt.Errorf("charge: %w", &testCreditError{
+		DetailsError: &DetailsError{Detail: "Your current balance is 30, but that costs 50."},
+		Balance:      30,
+		Accounts:     []string{"/account/12345", "/account/67890"},
+	})
+
+	if StatusCode(err) != http.StatusForbidden {
+		t.Fatal(StatusCode(err))
+	}
+
+	for _, accept := range []string{"application/json", "application/xml"} {
+		t.Run(accept, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Accept", accept)
+			ServeError(w, r, errors.Unwrap(err))
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusForbidden {
+				t.Fatal(resp.StatusCode)
+			} else if body := w.Body.String(); !strings.Contains(body, "https://example.com/probs/out-of-credit") {
+				t.Fatal(body)
+			}
+
+			var credit *testCreditError
+			if err := FromResponse(resp); !errors.As(err, &credit) {
+				t.Fatal(err)
+			} else if credit.Balance != 30 || len(credit.Accounts) != 2 || credit.Title != "You do not have enough credit." {
+				t.Fatal(credit)
+			} else if credit.Extensions != nil {
+				t.Fatal(credit.Extensions)
+			}
+
+			var details *DetailsError
+			if !errors.As(credit, &details) || details != credit.DetailsError {
+				t.Fatal(details)
+			}
+		})
+	}
+
+	t.Run("ValidationError", func(t *testing.T) {
+		var verr ValidationError
+		verr.Add("age", "must be a positive integer")
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		ServeError(w, r, verr.Err())
+
+		var details *DetailsError
+		if err := FromResponse(w.Result()); !errors.As(err, &details) {
+			t.Fatal(err)
+		} else if details.Status != http.StatusUnprocessableEntity || details.Type != ValidationType {
+			t.Fatal(details)
+		}
+	})
+
+	t.Run("unregistered", func(t *testing.T) {
+		resp := &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{"Content-Type": {"application/problem+json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"type":"https://example.com/probs/other","balance":30}`)),
+		}
+		var p *DetailsError
+		if err := FromResponse(resp); !errors.As(err, &p) || p.Extensions["balance"] == nil {
+			t.Fatal(err)
+		}
+	})
+}
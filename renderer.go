@@ -0,0 +1,354 @@
+package hproblem
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Renderer replies to requests with problem documents.
+// The zero value has no encoders registered; use NewRenderer instead.
+// The fields and encoders must not be modified while the Renderer is in use.
+type Renderer struct {
+	// DefaultType is the content type served if the request does not accept
+	// any of the registered content types.
+	// The first registered content type is used if it is empty.
+	DefaultType string
+
+	// Header holds the header fields that are set on every response.
+	// Responses to Status* errors share its values instead of copying them.
+	Header http.Header
+
+	// TypeBase is the absolute URI that relative Type URI references
+	// are resolved against.
+	TypeBase string
+
+	// Strict replies with 406 Not Acceptable, listing the registered
+	// content types in the detail and in the "available" extension member,
+	// if the request does not accept any of them.
+	// The problem is served in DefaultType or the first registered content type.
+	Strict bool
+
+	// ContextExtensions lists the names of the extension members
+	// added by WithContextExtension to the request context
+	// that are added to the problem documents.
+	// Other members in the request context are never rendered.
+	// If "trace_id" is listed and TraceBase is set, problems without
+	// an Instance field get one derived from the TraceParent added by Trace.
+	ContextExtensions []string
+
+	// TraceBase is the absolute URI that the trace identifier of a request
+	// is resolved against to derive the Instance field of its problems,
+	// followed by a slash and the span identifier if "span_id" is listed
+	// in ContextExtensions. No Instance field is derived if it is empty.
+	TraceBase string
+
+	// Prepare, if not nil, is called with the problem document
+	// right before it is encoded and may modify it.
+	Prepare func(r *http.Request, err error, p *DetailsError)
+
+	// OnCommitted, if not nil, is called with the error returned by
+	// a handler that has already written the response headers.
+	// The error is logged by the log package if it is nil.
+	OnCommitted func(w http.ResponseWriter, r *http.Request, err error)
+
+	// OnEncodeError, if not nil, is called if the problem document of err
+	// cannot be encoded. A minimal 500 Internal Server Error is served instead.
+	// The error is logged by the log package if it is nil.
+	OnEncodeError func(r *http.Request, err, encodeErr error)
+
+	// OnPanic, if not nil, is called with the panics recovered by Recover.
+	// The panic and its stack trace are logged by the log package if it is nil.
+	OnPanic func(r *http.Request, err *PanicError)
+
+	// OnRedact, if not nil, is called with the errors whose messages
+	// are redacted from the problems rendered by ServeError,
+	// and the occurrence identifiers that replace them.
+	// The package-level OnRedact is called instead if it is nil.
+	OnRedact func(r *http.Request, instance string, err error)
+
+	// Debug exposes the panics recovered by Recover to clients.
+	// It must not be set in production.
+	Debug bool
+
+	contentTypes []string
+	offers       []offer
+	encoders     map[string]Encoder
+
+	// contentTypeValues holds the Content-Type header field values
+	// of the registered content types, shared by the responses.
+	contentTypeValues map[string][]string
+}
+
+// NewRenderer returns a Renderer that serves plain text, JSON, XML,
+// CBOR and YAML, in that order of preference,
+// sets X-Content-Type-Options to nosniff
+// and renders the "trace_id" and "span_id" context extensions added by Trace.
+func NewRenderer() *Renderer {
+	rr := &Renderer{
+		Header:            http.Header{"X-Content-Type-Options": {"nosniff"}},
+		ContextExtensions: []string{"trace_id", "span_id"},
+	}
+	rr.Register("text/plain; charset=utf-8", TextEncoder)
+	rr.Register("application/problem+json; charset=utf-8", JSONEncoder)
+	rr.Register("application/problem+xml; charset=utf-8", XMLEncoder)
+	rr.Register("application/problem+cbor", CBOREncoder)
+	rr.Register("application/problem+yaml", YAMLEncoder)
+	return rr
+}
+
+// DefaultRenderer is the Renderer used by ServeError.
+var DefaultRenderer = NewRenderer()
+
+// Register associates a content type with an encoder.
+// Content types registered first are preferred if the request
+// accepts several of them equally.
+// Registering a content type again replaces its encoder.
+func (rr *Renderer) Register(contentType string, enc Encoder) {
+	if rr.encoders == nil {
+		rr.encoders = make(map[string]Encoder)
+		rr.contentTypeValues = make(map[string][]string)
+	}
+	if _, ok := rr.encoders[contentType]; !ok {
+		rr.contentTypes = append(rr.contentTypes, contentType)
+		rr.offers = parseOffers(rr.contentTypes)
+		rr.contentTypeValues[contentType] = []string{contentType}
+	}
+	rr.encoders[contentType] = enc
+}
+
+// ServeError replies to the request by rendering err.
+// If err implements http.Handler, its ServeHTTP method is called.
+// Otherwise, err is converted to a problem document by AsDetails and encoded in
+// the registered content type that best matches the request's Accept header.
+// Vary: Accept is added to the response if more than one content type is registered.
+// The problem document is encoded into a buffer first to set Content-Length.
+// No content is sent for status codes that forbid it, such as 204 No Content
+// and 304 Not Modified, nor in reply to HEAD requests.
+// Informational 1xx status codes are served as 500 Internal Server Error.
+// The header fields of the errors in the chain of err that implement
+// the Header() http.Header method are set on the response.
+// A relative Location header field of a 3xx response, such as set by Redirect,
+// is resolved against the request path.
+// If err is nil, it will be rendered as StatusOK.
+func (rr *Renderer) ServeError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		err = StatusOK
+	}
+
+	if h, ok := err.(http.Handler); ok { //nolint
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	contentType := negotiate(r, rr.offers)
+	if contentType == "" {
+		if rr.Strict {
+			err = New(http.StatusNotAcceptable,
+				WithDetail("None of the available content types is acceptable: "+strings.Join(rr.contentTypes, ", ")+"."),
+				WithExtension("available", append([]string(nil), rr.contentTypes...)),
+			)
+		}
+		contentType = rr.DefaultType
+	}
+	if _, ok := rr.encoders[contentType]; !ok && len(rr.contentTypes) > 0 {
+		contentType = rr.contentTypes[0]
+	}
+
+	members := rr.contextMembers(r.Context())
+
+	if _, ok := err.(statusError); ok && rr.Prepare == nil && members == nil && rr.serveStatus(w, r, err, contentType) {
+		return
+	}
+
+	p := rr.problem(r, err)
+	if p.Status < 200 {
+		// An informational status code cannot be that of the final response.
+		// It is a programming error rather than a failure worth redacting.
+		err = StatusInternalServerError
+		p = rr.problem(r, err)
+	}
+	for name, value := range members {
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]interface{})
+		}
+		if _, ok := p.Extensions[name]; !ok {
+			p.Extensions[name] = value
+		}
+	}
+	if _, ok := members["trace_id"]; ok && p.Instance == "" && rr.TraceBase != "" {
+		if tp, ok := TraceParentFromContext(r.Context()); ok {
+			_, withSpan := members["span_id"]
+			p.Instance = tp.instance(rr.TraceBase, withSpan)
+		}
+	}
+	if rr.Prepare != nil {
+		rr.Prepare(r, err, p)
+	}
+
+	header := headerOf(err)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if enc := rr.encoders[contentType]; enc != nil && bodyAllowed(p.Status) {
+		if encErr := enc.Encode(buf, p); encErr != nil {
+			rr.encodeFailed(r, err, encErr)
+
+			// Fall back to a minimal problem without the header fields of err.
+			header = nil
+			p = &DetailsError{
+				Status:       http.StatusInternalServerError,
+				Title:        StatusText(http.StatusInternalServerError),
+				wrappedError: err,
+			}
+			buf.Reset()
+			if enc.Encode(buf, p) != nil {
+				buf.Reset()
+				contentType = "text/plain; charset=utf-8"
+				buf.WriteString(p.Title + "\n")
+			}
+		}
+	}
+
+	h := w.Header()
+	for k, v := range rr.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	for k, v := range header {
+		h[k] = append([]string(nil), v...)
+	}
+	if p.Status >= 300 && p.Status < 400 {
+		resolveLocation(h, r)
+	}
+	if len(rr.contentTypes) > 1 {
+		addVary(h, "Accept")
+	}
+
+	if !bodyAllowed(p.Status) {
+		h.Del("Content-Length")
+		h.Del("Content-Type")
+		w.WriteHeader(p.Status)
+		return
+	}
+
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(p.Status)
+
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+// serveStatus writes the prebuilt encoding of the Status* error err if the encoder
+// of contentType is builtin, avoiding reflection and allocations.
+// It reports whether it did.
+//
+// The header field values are shared with rr.Header and between responses
+// rather than copied. They are written right away and must not be modified.
+func (rr *Renderer) serveStatus(w http.ResponseWriter, r *http.Request, err error, contentType string) bool {
+	code := int(err.(statusError))
+	enc, ok := rr.encoders[contentType].(*builtinEncoder)
+	sb := lookupStatusBody(code)
+	if !ok || sb == nil || StatusCode(err) != code {
+		return false
+	}
+
+	h := w.Header()
+	for k, v := range rr.Header {
+		// Appending to a full slice copies it.
+		h[k] = v[:len(v):len(v)]
+	}
+	if len(rr.contentTypes) > 1 {
+		if _, ok := h["Vary"]; ok {
+			addVary(h, "Accept")
+		} else {
+			h["Vary"] = varyAccept
+		}
+	}
+	h["Content-Type"] = rr.contentTypeValues[contentType]
+	h["Content-Length"] = sb.contentLengths[enc.format]
+	w.WriteHeader(code)
+
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(sb.bodies[enc.format])
+	}
+	return true
+}
+
+// encodeFailed reports that encoding the problem document of err failed.
+func (rr *Renderer) encodeFailed(r *http.Request, err, encodeErr error) {
+	if rr.OnEncodeError != nil {
+		rr.OnEncodeError(r, err, encodeErr)
+	} else {
+		log.Printf("hproblem: %s %s: encoding %v: %v", r.Method, r.URL.Path, err, encodeErr)
+	}
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool unless it has grown too large to keep.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= 64<<10 {
+		buf.Reset()
+		bufferPool.Put(buf)
+	}
+}
+
+// bodyAllowed reports whether a response with statusCode may have content.
+// See RFC 9110, Sections 6.4.1 and 15.3.6.
+func bodyAllowed(statusCode int) bool {
+	switch statusCode {
+	case http.StatusNoContent, http.StatusResetContent, http.StatusNotModified:
+		return false
+	default:
+		return statusCode >= 200
+	}
+}
+
+// varyAccept is the shared value of the Vary header field of the prebuilt responses.
+var varyAccept = []string{"Accept"}
+
+// addVary adds field to the Vary header field of h unless it is already listed.
+func addVary(h http.Header, field string) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f == "*" || strings.EqualFold(f, field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
+
+// problem converts err to a problem document.
+func (rr *Renderer) problem(r *http.Request, err error) *DetailsError {
+	occ := &occurrence{}
+	if rr.OnRedact != nil {
+		occ.report = func(instance string, err error) {
+			rr.OnRedact(r, instance, err)
+		}
+	}
+	p := asDetails(err, occ)
+
+	if rr.TypeBase != "" && p.Type != "" {
+		if ref, err := url.Parse(p.Type); err == nil && !ref.IsAbs() {
+			if base, err := url.Parse(rr.TypeBase); err == nil {
+				p.Type = base.ResolveReference(ref).String()
+			}
+		}
+	}
+
+	return p
+}
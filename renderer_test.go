@@ -0,0 +1,305 @@
+package hproblem
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRenderer(t *testing.T) {
+	rr := NewRenderer()
+	rr.DefaultType = "application/problem+json; charset=utf-8"
+	rr.Header.Set("Cache-Control", "no-store")
+	rr.TypeBase = "https://example.com/probs/"
+	rr.Prepare = func(r *http.Request, err error, p *DetailsError) {
+		p.Instance = r.URL.Path
+	}
+
+	t.Run("default", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/jedi", nil)
+		r.Header.Set("Accept", "image/png")
+		rr.ServeError(w, r, &DetailsError{Status: http.StatusNotFound, Type: "mind-trick"})
+		if w.Result().StatusCode != http.StatusNotFound {
+			t.Fatal()
+		} else if w.Header().Get("Content-Type") != "application/problem+json; charset=utf-8" {
+			t.Fatal(w.Header())
+		} else if w.Header().Get("Cache-Control") != "no-store" || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Fatal(w.Header())
+		} else if b := w.Body.String(); b != `{"instance":"/jedi","status":404,"title":"Not Found","type":"https://example.com/probs/mind-trick"}`+"\n" {
+			t.Fatal(b)
+		}
+	})
+
+	t.Run("register", func(t *testing.T) {
+		rr := NewRenderer()
+		rr.Register("text/html; charset=utf-8", EncoderFunc(func(w io.Writer, p *DetailsError) error {
+			_, err := io.WriteString(w, "<h1>"+p.Title+"</h1>")
+			return err
+		}))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "text/html")
+		rr.ServeError(w, r, StatusGone)
+		if w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+			t.Fatal(w.Header())
+		} else if b := w.Body.String(); b != "<h1>Gone</h1>" {
+			t.Fatal(b)
+		}
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest("GET", "/", nil)
+		ServeError(w, r, StatusGone)
+		if w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+			t.Fatal(w.Header())
+		}
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		ServeError(w, r, Public(errors.New("boom")))
+		if b := w.Body.String(); b != `{"detail":"boom","status":500,"title":"Internal Server Error"}`+"\n" {
+			t.Fatal(b)
+		}
+	})
+
+	t.Run("unmodified", func(t *testing.T) {
+		err := &DetailsError{Type: "mind-trick", Extensions: map[string]interface{}{"a": 1}}
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		rr.ServeError(w, r, err)
+		if err.Type != "mind-trick" || err.Instance != "" || err.Status != 0 {
+			t.Fatal(err)
+		} else if w.Result().StatusCode != http.StatusInternalServerError {
+			t.Fatal()
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		rr := NewRenderer()
+		rr.Strict = true
+		rr.DefaultType = "application/problem+json; charset=utf-8"
+		rr.Header.Set("Vary", "Origin")
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "image/png")
+		rr.ServeError(w, r, StatusNotFound)
+		if w.Result().StatusCode != http.StatusNotAcceptable {
+			t.Fatal(w.Result().StatusCode)
+		} else if v := w.Header().Values("Vary"); len(v) != 2 || v[0] != "Origin" || v[1] != "Accept" {
+			t.Fatal(v)
+		} else if b := w.Body.String(); b != `{"detail":"None of the available content types is acceptable: text/plain; charset=utf-8, application/problem+json; charset=utf-8, application/problem+xml; charset=utf-8, application/problem+cbor, application/problem+yaml.","status":406,"title":"Not Acceptable","available":["text/plain; charset=utf-8","application/problem+json; charset=utf-8","application/problem+xml; charset=utf-8","application/problem+cbor","application/problem+yaml"]}`+"\n" {
+			t.Fatal(b)
+		}
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest("GET", "/", nil)
+		rr.ServeError(w, r, StatusNotFound)
+		if w.Result().StatusCode != http.StatusNotFound {
+			t.Fatal(w.Result().StatusCode)
+		}
+
+		rr.DefaultType = ""
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "image/png")
+		rr.ServeError(w, r, StatusNotFound)
+		if b := w.Body.String(); b != "None of the available content types is acceptable: text/plain; charset=utf-8, application/problem+json; charset=utf-8, application/problem+xml; charset=utf-8, application/problem+cbor, application/problem+yaml.\n" {
+			t.Fatal(b)
+		}
+	})
+
+	t.Run("bodyless", func(t *testing.T) {
+		for _, err := range []error{StatusNoContent, StatusResetContent, StatusNotModified} {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			ServeError(w, r, err)
+			if w.Result().StatusCode != StatusCode(err) || w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+				t.Fatal(err, w.Header(), w.Body.String())
+			}
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("HEAD", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		ServeError(w, r, StatusNotFound)
+		if w.Result().StatusCode != http.StatusNotFound || w.Body.Len() != 0 {
+			t.Fatal(w.Body.String())
+		} else if w.Header().Get("Content-Length") != "56" {
+			t.Fatal(w.Header())
+		}
+	})
+
+	t.Run("OnRedact", func(t *testing.T) {
+		OnRedact = func(instance string, err error) { t.Error("package OnRedact", err) }
+		defer func() { OnRedact = nil }()
+
+		var instance string
+		var redacted error
+		rr := NewRenderer()
+		rr.OnRedact = func(r *http.Request, id string, err error) {
+			instance, redacted = id, err
+		}
+
+		err := Wrap(http.StatusInternalServerError, errors.New("secret"))
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		rr.ServeError(w, r, err)
+		if redacted != err {
+			t.Fatal(redacted)
+		} else if b := w.Body.String(); b != `{"detail":"Internal Server Error","instance":"`+instance+`","status":500,"title":"Internal Server Error"}`+"\n" {
+			t.Fatal(b)
+		}
+	})
+
+	t.Run("shared header", func(t *testing.T) {
+		rr := NewRenderer()
+		rr.Header.Add("Link", "</a>")
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		rr.ServeError(w, r, StatusNotFound)
+		w.Header().Add("Link", "</b>")
+		w.Header().Add("Vary", "Origin")
+		if v := rr.Header.Values("Link"); len(v) != 1 || v[0] != "</a>" {
+			t.Fatal(v)
+		} else if len(varyAccept) != 1 || varyAccept[0] != "Accept" {
+			t.Fatal(varyAccept)
+		}
+
+		dw := &discardResponseWriter{h: make(http.Header)}
+		if n := testing.AllocsPerRun(10, func() {
+			for k := range dw.h {
+				delete(dw.h, k)
+			}
+			rr.ServeError(dw, r, StatusNotFound)
+		}); n != 0 {
+			t.Fatal(n)
+		}
+	})
+
+	t.Run("informational", func(t *testing.T) {
+		var redacted bool
+		OnRedact = func(instance string, err error) { redacted = true }
+		defer func() { OnRedact = nil }()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		ServeError(w, r, StatusContinue)
+		if w.Result().StatusCode != http.StatusInternalServerError {
+			t.Fatal(w.Result().StatusCode)
+		} else if w.Body.String() != `{"detail":"Internal Server Error","status":500,"title":"Internal Server Error"}`+"\n" {
+			t.Fatal(w.Body.String())
+		} else if redacted {
+			t.Fatal("redacted")
+		}
+	})
+
+	t.Run("encode error", func(t *testing.T) {
+		rr := NewRenderer()
+		var encodeErr error
+		rr.OnEncodeError = func(r *http.Request, err, e error) { encodeErr = e }
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		err := &DetailsError{Status: http.StatusConflict, Extensions: map[string]interface{}{"bad": failingMarshaler{}}}
+		rr.ServeError(w, r, WithRetryAfter(err, time.Second))
+		if encodeErr == nil {
+			t.Fatal()
+		} else if w.Result().StatusCode != http.StatusInternalServerError || w.Header().Get("Retry-After") != "" {
+			t.Fatal(w.Result().StatusCode, w.Header())
+		} else if b := w.Body.String(); b != `{"status":500,"title":"Internal Server Error"}`+"\n" {
+			t.Fatal(b)
+		} else if w.Header().Get("Content-Length") != strconv.Itoa(len(b)) {
+			t.Fatal(w.Header())
+		}
+
+		for _, accept := range []string{"application/json", "application/xml", "application/problem+cbor", "application/problem+yaml"} {
+			cyclic := map[string]interface{}{}
+			cyclic["x"] = []interface{}{cyclic}
+
+			encodeErr = nil
+			w = httptest.NewRecorder()
+			r = httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Accept", accept)
+			rr.ServeError(w, r, New(http.StatusBadRequest, WithExtension("cyc", cyclic)))
+			if encodeErr == nil || w.Result().StatusCode != http.StatusInternalServerError {
+				t.Fatal(accept, w.Result().StatusCode)
+			}
+		}
+	})
+}
+
+func TestPrebuiltStatus(t *testing.T) {
+	slow := NewRenderer()
+	slow.Prepare = func(r *http.Request, err error, p *DetailsError) {}
+
+	for _, accept := range []string{"text/plain", "application/json", "application/xml", "application/problem+cbor"} {
+		for _, err := range []error{StatusNotFound, StatusTeapot, StatusInternalServerError, StatusNoContent} {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Accept", accept)
+
+			fast := httptest.NewRecorder()
+			ServeError(fast, r, err)
+			want := httptest.NewRecorder()
+			slow.ServeError(want, r, err)
+
+			if fast.Code != want.Code || fast.Body.String() != want.Body.String() {
+				t.Fatal(accept, err, fast.Body.String(), want.Body.String())
+			} else if !reflect.DeepEqual(fast.Header(), want.Header()) {
+				t.Fatal(accept, err, fast.Header(), want.Header())
+			}
+		}
+	}
+}
+
+// discardResponseWriter is a ResponseWriter that reuses its header map.
+type discardResponseWriter struct{ h http.Header }
+
+func (w *discardResponseWriter) Header() http.Header         { return w.h }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
+func BenchmarkServeError(b *testing.B) {
+	for _, accept := range []string{"text/plain", "application/json", "application/xml"} {
+		for _, bench := range []struct {
+			Name string
+			Err  error
+		}{
+			{"sentinel", StatusNotFound},
+			{"problem", &DetailsError{Status: http.StatusNotFound, Type: "https://example.com/probs/no-jedi"}},
+		} {
+			b.Run(accept+"/"+bench.Name, func(b *testing.B) {
+				w := &discardResponseWriter{h: make(http.Header)}
+				r := httptest.NewRequest("GET", "/", nil)
+				r.Header.Set("Accept", accept)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					for k := range w.h {
+						delete(w.h, k)
+					}
+					ServeError(w, r, bench.Err)
+				}
+			})
+		}
+	}
+}
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) { return nil, errors.New("failing") }
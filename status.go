@@ -1,13 +1,19 @@
 package hproblem
 
 import (
+	"bytes"
 	"encoding/xml"
-	"fmt"
-	"net/http"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
 )
 
 // HTTP status codes as registered with IANA.
 // See: https://www.iana.org/assignments/http-status-codes/http-status-codes.xhtml
+//
+// errors.Is reports that a DetailsError, or an error returned by Wrap,
+// Errorf or New, matches the status code of the same value.
 const (
 	StatusContinue                      statusError = 100 // RFC 9110, 15.2.1
 	StatusSwitchingProtocols            statusError = 101 // RFC 9110, 15.2.2
@@ -73,23 +79,80 @@ const (
 	StatusNetworkAuthenticationRequired statusError = 511 // RFC 6585, 6
 )
 
-// statusJSONS prebuilds the json responses for the standard status codes.
-var statusJSONS map[int][]byte = func() map[int][]byte {
-	jsons := make(map[int][]byte)
-	for code := 100; code < 600; code++ {
-		text := http.StatusText(code)
-		if text != "" {
-			json := fmt.Sprintf(`{"detail":"%s","status":%d,"title":"%s"}`, text, code, text)
-			jsons[code] = []byte(json)
+// statusBody holds the prebuilt encodings of a Status* error
+// in each format of the builtin encoders,
+// and the values of their Content-Length header fields.
+type statusBody struct {
+	bodies         [numFormats][]byte
+	contentLengths [numFormats][]string
+}
+
+// statusBodies holds the prebuilt encodings of the Status* errors
+// for the status codes in the range 200-599 that allow content.
+// It is a map[int]*statusBody that is replaced as a whole
+// when a status text is registered.
+var statusBodies atomic.Value
+
+func init() {
+	bodies := make(map[int]*statusBody)
+	for code := 200; code <= 599; code++ {
+		if bodyAllowed(code) {
+			bodies[code] = newStatusBody(code)
+		}
+	}
+	statusBodies.Store(bodies)
+}
+
+var prebuildMu sync.Mutex
+
+// prebuildStatusBody rebuilds the prebuilt encodings of code.
+func prebuildStatusBody(code int) {
+	if code < 200 || !bodyAllowed(code) {
+		return
+	}
+
+	prebuildMu.Lock()
+	defer prebuildMu.Unlock()
+
+	old := statusBodies.Load().(map[int]*statusBody)
+	bodies := make(map[int]*statusBody, len(old))
+	for k, v := range old {
+		bodies[k] = v
+	}
+	bodies[code] = newStatusBody(code)
+	statusBodies.Store(bodies)
+}
+
+func lookupStatusBody(code int) *statusBody {
+	return statusBodies.Load().(map[int]*statusBody)[code]
+}
+
+func newStatusBody(code int) *statusBody {
+	encoders := [numFormats]func(io.Writer, *DetailsError) error{
+		formatJSON: encodeJSON,
+		formatXML:  encodeXML,
+		formatText: encodeText,
+	}
+
+	text := StatusText(code)
+	p := &DetailsError{Detail: text, Status: code, Title: text}
+
+	sb := &statusBody{}
+	for format, encode := range encoders {
+		var buf bytes.Buffer
+		if err := encode(&buf, p); err != nil {
+			panic(err)
 		}
+		sb.bodies[format] = buf.Bytes()
+		sb.contentLengths[format] = []string{strconv.Itoa(buf.Len())}
 	}
-	return jsons
-}()
+	return sb
+}
 
 type statusError int
 
 func (err statusError) Error() string {
-	return http.StatusText(err.StatusCode())
+	return StatusText(err.StatusCode())
 }
 
 func (err statusError) StatusCode() int {
@@ -97,19 +160,27 @@ func (err statusError) StatusCode() int {
 }
 
 func (err statusError) MarshalJSON() ([]byte, error) {
-	return statusJSONS[int(err)], nil
+	if sb := lookupStatusBody(int(err)); sb != nil {
+		b := sb.bodies[formatJSON]
+		return b[:len(b)-1], nil
+	}
+	return marshalJSON(NewDetailsError(err))
 }
 
 func (err statusError) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	title := err.Error()
-	return e.Encode(struct {
-		Detail  string   `xml:"detail"`
-		Status  int      `xml:"status"`
-		Title   string   `xml:"title"`
-		XMLName xml.Name `xml:"urn:ietf:rfc:7807 problem"`
-	}{
-		Detail: title,
-		Status: err.StatusCode(),
-		Title:  title,
-	})
+	start = xml.StartElement{Name: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, member := range [...]struct{ name, value string }{
+		{"detail", title},
+		{"status", strconv.Itoa(int(err))},
+		{"title", title},
+	} {
+		if err := e.EncodeElement(member.value, xml.StartElement{Name: xml.Name{Local: member.name}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
 }
@@ -0,0 +1,70 @@
+package hproblem
+
+import (
+	"net/http"
+	"sync"
+)
+
+var statusTexts = struct {
+	sync.RWMutex
+	m map[int]string
+}{
+	m: map[int]string{
+		StatusClientClosedRequest: "Client Closed Request",
+	},
+}
+
+// RegisterStatusText registers the text of a status code in the range 100-599,
+// typically one that is not known to http.StatusText, such as a vendor code.
+// Registered texts take precedence over those of http.StatusText.
+// 499 Client Closed Request is registered by default.
+// RegisterStatusText panics if code is out of range.
+func RegisterStatusText(code int, text string) {
+	if !validStatus(code) {
+		panic("hproblem: status code out of range")
+	}
+
+	statusTexts.Lock()
+	statusTexts.m[code] = text
+	statusTexts.Unlock()
+
+	prebuildStatusBody(code)
+}
+
+// StatusText returns the text of a status code that is registered
+// by RegisterStatusText or known to http.StatusText.
+// The text of other status codes in the range 100-599
+// is the name of their class, such as "Client Error" for 420.
+// It returns the empty string for status codes out of range,
+// which are served as 500 Internal Server Error.
+func StatusText(code int) string {
+	statusTexts.RLock()
+	text, ok := statusTexts.m[code]
+	statusTexts.RUnlock()
+	if ok {
+		return text
+	} else if text = http.StatusText(code); text != "" {
+		return text
+	}
+
+	switch {
+	case !validStatus(code):
+		return ""
+	case code < 200:
+		return "Informational"
+	case code < 300:
+		return "Successful"
+	case code < 400:
+		return "Redirection"
+	case code < 500:
+		return "Client Error"
+	default:
+		return "Server Error"
+	}
+}
+
+// validStatus reports whether code is in the range 100-599
+// defined by RFC 9110, Section 15.
+func validStatus(code int) bool {
+	return code >= 100 && code <= 599
+}
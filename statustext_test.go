@@ -0,0 +1,61 @@
+package hproblem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusText(t *testing.T) {
+	for _, test := range []struct {
+		Code int
+		Text string
+	}{
+		{http.StatusNotFound, "Not Found"},
+		{StatusClientClosedRequest, "Client Closed Request"},
+		{299, "Successful"},
+		{521, "Server Error"},
+		{42, ""},
+		{600, ""},
+	} {
+		if text := StatusText(test.Code); text != test.Text {
+			t.Fatal(test.Code, text)
+		}
+	}
+
+	t.Run("register", func(t *testing.T) {
+		RegisterStatusText(420, "Enhance Your Calm")
+
+		if b, err := json.Marshal(statusError(420)); err != nil || string(b) != `{"detail":"Enhance Your Calm","status":420,"title":"Enhance Your Calm"}` {
+			t.Fatal(string(b), err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		ServeError(w, r, statusError(420))
+		if b := w.Body.String(); b != `{"detail":"Enhance Your Calm","status":420,"title":"Enhance Your Calm"}`+"\n" {
+			t.Fatal(b)
+		}
+
+		if details := NewDetailsError(Wrap(420, errors.New("slow down"))); details.Title != "Enhance Your Calm" || details.Detail != "slow down" {
+			t.Fatal(details)
+		}
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		details := NewDetailsError(Wrap(42, errors.New("answer")))
+		if details.Status != http.StatusInternalServerError || details.Title != "Internal Server Error" {
+			t.Fatal(details)
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal()
+			}
+		}()
+		RegisterStatusText(600, "Six Hundred")
+	})
+}
@@ -0,0 +1,121 @@
+package hproblem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TraceParent identifies a request in a distributed trace
+// as specified by W3C Trace Context.
+// See: https://www.w3.org/TR/trace-context/#traceparent-header
+type TraceParent struct {
+	// TraceID is the 32 lowercase hexadecimal digits of the trace.
+	TraceID string
+
+	// SpanID is the 16 lowercase hexadecimal digits of the span.
+	SpanID string
+
+	// Flags holds the trace flags, such as 0x01 if the trace is sampled.
+	Flags byte
+}
+
+// ParseTraceParent parses the value of a traceparent header field.
+// Fields following the trace flags of future versions are ignored.
+func ParseTraceParent(s string) (TraceParent, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 55 || (len(s) > 55 && s[55] != '-') ||
+		s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return TraceParent{}, false
+	}
+
+	version, traceID, spanID, flags := s[0:2], s[3:35], s[36:52], s[53:55]
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(s) != 55) {
+		return TraceParent{}, false
+	} else if !isLowerHex(traceID) || traceID == strings.Repeat("0", 32) {
+		return TraceParent{}, false
+	} else if !isLowerHex(spanID) || spanID == strings.Repeat("0", 16) {
+		return TraceParent{}, false
+	} else if !isLowerHex(flags) {
+		return TraceParent{}, false
+	}
+
+	b, _ := hex.DecodeString(flags)
+	return TraceParent{TraceID: traceID, SpanID: spanID, Flags: b[0]}, true
+}
+
+// String returns the value of the traceparent header field for tp.
+func (tp TraceParent) String() string {
+	return "00-" + tp.TraceID + "-" + tp.SpanID + "-" + hex.EncodeToString([]byte{tp.Flags})
+}
+
+// instance returns the URI that identifies the occurrence of a problem in tp,
+// which is the trace identifier resolved against base.
+// It is followed by a slash and the span identifier if withSpan is set.
+// It returns the empty string if base is not a valid URI.
+func (tp TraceParent) instance(base string, withSpan bool) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	ref := &url.URL{Path: tp.TraceID}
+	if withSpan {
+		ref.Path += "/" + tp.SpanID
+	}
+	return u.ResolveReference(ref).String()
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type traceParentKey struct{}
+
+// TraceParentFromContext returns the TraceParent added to ctx by Trace.
+func TraceParentFromContext(ctx context.Context) (TraceParent, bool) {
+	tp, ok := ctx.Value(traceParentKey{}).(TraceParent)
+	return tp, ok
+}
+
+// Trace returns middleware that correlates the problems served
+// while handling a request with its distributed trace.
+//
+// The request continues the trace of its traceparent header field,
+// or starts a new trace if it has none or it is invalid.
+// Either way, the request is identified by a new span.
+// Its TraceParent is available to next through TraceParentFromContext,
+// and its trace and span identifiers are added to the request context
+// as the "trace_id" and "span_id" extension members by WithContextExtension.
+//
+// Renderers created by NewRenderer render these extension members.
+// Renderers whose TraceBase is set also set the Instance field of problems
+// that do not have one to the trace identifier resolved against TraceBase,
+// followed by a slash and the span identifier if they render "span_id".
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tp, ok := ParseTraceParent(r.Header.Get("Traceparent"))
+		if !ok {
+			tp = TraceParent{TraceID: randomHex(16)}
+		}
+		tp.SpanID = randomHex(8)
+
+		ctx := context.WithValue(r.Context(), traceParentKey{}, tp)
+		ctx = WithContextExtension(ctx, "trace_id", tp.TraceID)
+		ctx = WithContextExtension(ctx, "span_id", tp.SpanID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,115 @@
+package hproblem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	for _, test := range []struct {
+		Value string
+		OK    bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{" 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00 ", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+		{"", false},
+	} {
+		tp, ok := ParseTraceParent(test.Value)
+		if ok != test.OK {
+			t.Fatal(test.Value)
+		} else if ok && (tp.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tp.SpanID != "00f067aa0ba902b7") {
+			t.Fatal(tp)
+		}
+	}
+
+	tp := TraceParent{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Flags: 1}
+	if s := tp.String(); s != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatal(s)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var tp TraceParent
+	handler := Trace(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		tp, _ = TraceParentFromContext(r.Context())
+		return StatusNotFound
+	}))
+
+	t.Run("continue", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		handler.ServeHTTP(w, r)
+
+		if tp.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tp.Flags != 1 {
+			t.Fatal(tp)
+		} else if _, ok := ParseTraceParent(tp.String()); !ok || tp.SpanID == "00f067aa0ba902b7" {
+			t.Fatal(tp)
+		}
+
+		var p map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		} else if p["trace_id"] != tp.TraceID || p["span_id"] != tp.SpanID {
+			t.Fatal(p)
+		} else if _, ok := p["instance"]; ok || p["status"] != 404.0 {
+			t.Fatal(p)
+		}
+	})
+
+	t.Run("start", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Traceparent", "garbage")
+		handler.ServeHTTP(w, r)
+
+		if _, ok := ParseTraceParent(tp.String()); !ok || tp.Flags != 0 {
+			t.Fatal(tp)
+		} else if tp.TraceID == "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatal(tp)
+		}
+	})
+
+	t.Run("instance", func(t *testing.T) {
+		rr := NewRenderer()
+		rr.TraceBase = "https://example.com/traces/"
+		handler := Trace(rr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			tp, _ = TraceParentFromContext(r.Context())
+			return StatusNotFound
+		}))
+
+		serve := func() map[string]interface{} {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Accept", "application/json")
+			handler.ServeHTTP(w, r)
+
+			var p map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			return p
+		}
+
+		if p := serve(); p["instance"] != "https://example.com/traces/"+tp.TraceID+"/"+tp.SpanID {
+			t.Fatal(p)
+		}
+
+		rr.ContextExtensions = []string{"trace_id"}
+		if p := serve(); p["span_id"] != nil || p["trace_id"] != tp.TraceID {
+			t.Fatal(p)
+		} else if p["instance"] != "https://example.com/traces/"+tp.TraceID {
+			t.Fatal(p)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package hproblem
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ValidationType is the type URI of ValidationError.
+const ValidationType = "https://pkg.go.dev/github.com/askeladdk/hproblem#ValidationError"
+
+func init() {
+	RegisterType(ValidationType, "Your request parameters didn't validate.", http.StatusUnprocessableEntity, (*ValidationError)(nil))
+}
+
+// InvalidParam describes why a request parameter is invalid.
+type InvalidParam struct {
+	// Name locates the parameter. It is a JSON Pointer for members
+	// of the request body and the name of the parameter otherwise.
+	Name string `json:"name" xml:"name"`
+
+	// Reason is a human-readable explanation of the violation.
+	Reason string `json:"reason" xml:"reason"`
+
+	// Code is an optional machine-readable identifier of the violation.
+	Code string `json:"code,omitempty" xml:"code,omitempty"`
+
+	// Offset is the optional byte offset in the request body
+	// where the violation was found.
+	Offset int64 `json:"offset,omitempty" xml:"offset,omitempty"`
+}
+
+// ValidationError is a problem that lists the invalid parameters of a request
+// in the "invalid-params" extension member, following RFC 7807, Section 3.
+// It is registered with the type URI ValidationType
+// and the status code 422 Unprocessable Entity by default,
+// so that FromResponse decodes it as a *ValidationError.
+//
+//	var verr hproblem.ValidationError
+//	if req.Age < 0 {
+//	    verr.Add(hproblem.JSONPointer("age"), "must be a positive integer")
+//	}
+//	if err := verr.Err(); err != nil {
+//	    hproblem.ServeError(w, r, err)
+//	}
+type ValidationError struct {
+	*DetailsError
+	InvalidParams []InvalidParam `json:"invalid-params" xml:"invalid-params>i"`
+}
+
+// Add adds an invalid parameter.
+func (err *ValidationError) Add(name, reason string) {
+	err.AddCode(name, "", reason)
+}
+
+// AddCode adds an invalid parameter with a machine-readable code.
+func (err *ValidationError) AddCode(name, code, reason string) {
+	if err.DetailsError == nil {
+		err.DetailsError = &DetailsError{}
+	}
+	err.InvalidParams = append(err.InvalidParams, InvalidParam{
+		Name:   name,
+		Reason: reason,
+		Code:   code,
+	})
+}
+
+// Err returns err if it has any invalid parameters and nil otherwise.
+func (err *ValidationError) Err() error {
+	if len(err.InvalidParams) == 0 {
+		return nil
+	}
+	return err
+}
+
+// Error implements the error interface and returns the Detail field
+// if it is set, or a summary of the invalid parameters otherwise.
+func (err *ValidationError) Error() string {
+	if err.DetailsError != nil && err.Detail != "" {
+		return err.Detail
+	}
+
+	var sb strings.Builder
+	sb.WriteString("invalid parameters")
+	for i, p := range err.InvalidParams {
+		if i == 0 {
+			sb.WriteString(": ")
+		} else {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(p.Name + ": " + p.Reason)
+	}
+	return sb.String()
+}
+
+// StatusCode implements the interface used by StatusCode and returns
+// the Status field if it is set, or the registered status code otherwise.
+func (err *ValidationError) StatusCode() int {
+	if err.DetailsError != nil && err.Status != 0 {
+		return err.Status
+	}
+	if pt := lookupGoType(err); pt != nil {
+		return pt.status
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// Is reports whether target is the Status* error of its status code
+// or the Kind of its type.
+func (err *ValidationError) Is(target error) bool {
+	typeURI := ValidationType
+	if err.DetailsError != nil && err.Type != "" {
+		typeURI = err.Type
+	} else if pt := lookupGoType(err); pt != nil {
+		typeURI = pt.uri
+	}
+	return isProblem(target, err.StatusCode(), typeURI)
+}
+
+// JSONPointer returns the RFC 6901 JSON Pointer made of the reference tokens,
+// such as "/items/0/name" for JSONPointer("items", "0", "name").
+func JSONPointer(tokens ...string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(jsonPointerEscaper.Replace(token))
+	}
+	return sb.String()
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
@@ -0,0 +1,63 @@
+package hproblem
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestValidationError(t *testing.T) {
+	var verr ValidationError
+	if verr.Err() != nil {
+		t.Fatal()
+	}
+
+	verr.Add(JSONPointer("age"), "must be a positive integer")
+	verr.AddCode("color", "enum", "must be 'green', 'red' or 'blue'")
+
+	err := verr.Err()
+	if err == nil {
+		t.Fatal()
+	} else if err.Error() != "invalid parameters: /age: must be a positive integer; color: must be 'green', 'red' or 'blue'" {
+		t.Fatal(err.Error())
+	} else if StatusCode(err) != http.StatusUnprocessableEntity {
+		t.Fatal(StatusCode(err))
+	} else if !errors.Is(err, StatusUnprocessableEntity) || !errors.Is(err, Kind(ValidationType)) {
+		t.Fatal()
+	}
+
+	for _, accept := range []string{"application/json", "application/xml"} {
+		t.Run(accept, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Accept", accept)
+			ServeError(w, r, err)
+
+			if accept == "application/json" {
+				if b := w.Body.String(); b != `{"status":422,"title":"Your request parameters didn't validate.","type":"`+ValidationType+`","invalid-params":[{"name":"/age","reason":"must be a positive integer"},{"code":"enum","name":"color","reason":"must be 'green', 'red' or 'blue'"}]}`+"\n" {
+					t.Fatal(b)
+				}
+			}
+
+			decoded := FromResponse(w.Result())
+			var v *ValidationError
+			if !errors.As(decoded, &v) {
+				t.Fatal(decoded)
+			} else if !reflect.DeepEqual(v.InvalidParams, verr.InvalidParams) {
+				t.Fatal(v.InvalidParams)
+			} else if v.Status != http.StatusUnprocessableEntity || v.Extensions != nil {
+				t.Fatal(v.DetailsError)
+			}
+		})
+	}
+}
+
+func TestJSONPointer(t *testing.T) {
+	if p := JSONPointer("a/b", "m~n", "0"); p != "/a~1b/m~0n/0" {
+		t.Fatal(p)
+	} else if p := JSONPointer(); p != "" {
+		t.Fatal(p)
+	}
+}
@@ -0,0 +1,110 @@
+package hproblem
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// YAMLEncoder encodes problems as YAML block mappings
+// with the same members as JSONEncoder.
+// Strings are always double-quoted.
+var YAMLEncoder Encoder = EncoderFunc(encodeYAML)
+
+func encodeYAML(w io.Writer, p *DetailsError) error {
+	members, err := problemMembers(p)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, m := range members {
+		writeYAMLKey(&buf, m.name)
+		writeYAMLValue(&buf, m.value, 2)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func writeYAMLKey(buf *bytes.Buffer, key string) {
+	if isYAMLPlain(key) {
+		buf.WriteString(key)
+	} else {
+		writeYAMLString(buf, key)
+	}
+	buf.WriteByte(':')
+}
+
+// writeYAMLValue writes the value following a key or sequence indicator.
+// Nested collections are indented by indent spaces.
+func writeYAMLValue(buf *bytes.Buffer, v interface{}, indent int) {
+	switch v := v.(type) {
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+		buf.WriteByte('\n')
+		for _, item := range v {
+			buf.WriteString(strings.Repeat(" ", indent))
+			buf.WriteByte('-')
+			writeYAMLValue(buf, item, indent+2)
+		}
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString(" {}\n")
+			return
+		}
+		buf.WriteByte('\n')
+		for _, k := range sortedKeys(v) {
+			buf.WriteString(strings.Repeat(" ", indent))
+			writeYAMLKey(buf, k)
+			writeYAMLValue(buf, v[k], indent+2)
+		}
+	default:
+		buf.WriteByte(' ')
+		writeYAMLScalar(buf, v)
+		buf.WriteByte('\n')
+	}
+}
+
+func writeYAMLScalar(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		writeYAMLString(buf, v)
+	case nil:
+		buf.WriteString("null")
+	default:
+		// Numbers and booleans are written the same as in JSON.
+		b, _ := json.Marshal(v)
+		buf.Write(b)
+	}
+}
+
+// writeYAMLString writes s as a double-quoted scalar.
+// The JSON escape sequences are a subset of those of YAML.
+func writeYAMLString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1)
+}
+
+// isYAMLPlain reports whether key can be written without quotes.
+func isYAMLPlain(key string) bool {
+	switch strings.ToLower(key) {
+	case "", "y", "n", "yes", "no", "on", "off", "true", "false", "null":
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-'):
+		default:
+			return false
+		}
+	}
+	return true
+}